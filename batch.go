@@ -0,0 +1,101 @@
+package bayesian
+
+import (
+	"context"
+	"runtime"
+)
+
+// BatchOptions configures ScoreBatch
+type BatchOptions struct {
+	// Workers is how many documents are scored at once, GOMAXPROCS when it is 0 or less
+	Workers int
+	// Buffer is how many documents may be scored ahead of the one the reader is waiting for, Workers when it is 0 or
+	// less. It bounds the memory held for results that cannot be delivered yet.
+	Buffer int
+}
+
+// BatchResult is the score of one document of a batch
+type BatchResult struct {
+	// Index is the position of the document in the batch
+	Index  int
+	Result *ScoreResult
+	Err    error
+}
+
+// batchJob is a document waiting for a worker along with where its result goes
+type batchJob struct {
+	index int
+	doc   []string
+	out   chan BatchResult
+}
+
+// ScoreBatch scores documents on a pool of workers and delivers the results in the order of the documents. A document
+// that fails to score gets a result with its error and the rest of the batch carries on. If ctx is done the channel
+// is closed early, without the remaining results, and ctx.Err() tells why. Read the channel until it is closed or
+// cancel ctx, otherwise the workers are left waiting.
+func ScoreBatch(ctx context.Context, c Classifier, docs [][]string, opts BatchOptions) <-chan BatchResult {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	buffer := opts.Buffer
+	if buffer <= 0 {
+		buffer = workers
+	}
+
+	jobs := make(chan batchJob)
+	// pending holds the result channel of each dispatched document in order, its capacity bounds how far the workers
+	// get ahead of the reader
+	pending := make(chan chan BatchResult, buffer)
+	results := make(chan BatchResult)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				result, err := c.Score(job.doc)
+				job.out <- BatchResult{Index: job.index, Result: result, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+
+		for i, doc := range docs {
+			out := make(chan BatchResult, 1)
+			select {
+			case pending <- out:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case jobs <- batchJob{index: i, doc: doc, out: out}:
+			case <-ctx.Done():
+				// the document is already pending, so it has to be answered
+				out <- BatchResult{Index: i, Err: ctx.Err()}
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer close(results)
+
+		for out := range pending {
+			result := <-out
+			if ctx.Err() != nil {
+				return
+			}
+
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results
+}
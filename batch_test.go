@@ -0,0 +1,97 @@
+package bayesian
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreBatch(t *testing.T) {
+	c := syntheticCorpus(t, 3, 200, 300)
+	docs := syntheticDocs(500, 250)
+
+	for _, opts := range []BatchOptions{{}, {Workers: 1}, {Workers: 8, Buffer: 2}} {
+		i := 0
+		for r := range ScoreBatch(context.Background(), c, docs, opts) {
+			assert.Equal(t, i, r.Index)
+			assert.NoError(t, r.Err)
+
+			want, err := c.Score(docs[i])
+			assert.NoError(t, err)
+			assert.Equal(t, want.Posteriors, r.Result.Posteriors)
+			i++
+		}
+		assert.Equal(t, len(docs), i)
+	}
+
+	// failures are reported per document
+	untrained, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+	n := 0
+	for r := range ScoreBatch(context.Background(), untrained, docs[:10], BatchOptions{Workers: 3}) {
+		assert.Equal(t, ErrUntrained, r.Err)
+		assert.Nil(t, r.Result)
+		n++
+	}
+	assert.Equal(t, 10, n)
+
+	for range ScoreBatch(context.Background(), c, nil, BatchOptions{}) {
+		t.Fatal("an empty batch has no results")
+	}
+}
+
+func TestScoreBatchCancel(t *testing.T) {
+	c := syntheticCorpus(t, 3, 200, 300)
+	docs := syntheticDocs(10000, 250)
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	for r := range ScoreBatch(ctx, c, docs, BatchOptions{Workers: 4}) {
+		assert.Equal(t, n, r.Index)
+		n++
+		if n == 100 {
+			cancel()
+		}
+	}
+	assert.True(t, n >= 100 && n < len(docs))
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	n = 0
+	for range ScoreBatch(ctx, c, docs, BatchOptions{Workers: 4}) {
+		n++
+	}
+	assert.True(t, n < len(docs))
+
+	// every worker finishes once the batch is abandoned
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.True(t, runtime.NumGoroutine() <= before)
+}
+
+func TestScoreBatchConcurrentLearn(t *testing.T) {
+	c := syntheticCorpus(t, 2, 100, 50)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, doc := range syntheticDocs(100, 100) {
+			assert.NoError(t, c.Learn(doc, Positive))
+		}
+	}()
+
+	n := 0
+	for r := range ScoreBatch(context.Background(), c, syntheticDocs(200, 100), BatchOptions{Workers: 4}) {
+		assert.NoError(t, r.Err)
+		n++
+	}
+	<-done
+	assert.Equal(t, 200, n)
+}
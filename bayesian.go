@@ -2,7 +2,10 @@
 package bayesian
 
 import (
+	"math"
 	"math/big"
+	"sync"
+	"time"
 
 	"errors"
 
@@ -13,8 +16,12 @@ import (
 
 // Classifier is an interface to a general multi-class bayesian classifier
 type Classifier interface {
-	Scores(doc []string) ([]*big.Float, int, bool)
+	Scores(doc []string) ([]*big.Float, int, bool, error)
+	Score(doc []string) (*ScoreResult, error)
 	Learn(doc []string, category int) error
+	LearnBatch(docs []Document) error
+	Begin() *Tx
+	NewScorer() *Scorer
 }
 
 // Positive represents the positive category in a binary classifier
@@ -25,7 +32,8 @@ const Negative = 0
 
 // BinaryClassifier is an interface to a simple bayesian binary classifier
 type BinaryClassifier interface {
-	Scores(doc []string) ([]*big.Float, int, bool)
+	Scores(doc []string) ([]*big.Float, int, bool, error)
+	Score(doc []string) (*ScoreResult, error)
 	LearnPositive(doc []string) error
 	LearnNegative(doc []string) error
 }
@@ -33,16 +41,48 @@ type BinaryClassifier interface {
 type classifier struct {
 	Tree            radix.Tree
 	SmoothingFactor float64
+	// Smoother is the smoothing strategy, additive smoothing by SmoothingFactor is used when it is nil
+	Smoother Smoother
+	// Unknown is how words that were never learned are scored
+	Unknown UnknownPolicy
+	// AutoDecay keeps the clock of a decayed tree at the current time, instead of moving it with Decay
+	AutoDecay bool
+	// FuzzyEdits is how far an unseen word may be from the known word whose counts it borrows, 0 turns it off
+	FuzzyEdits int
+	// FuzzyPenalty scales the borrowed counts once per edit
+	FuzzyPenalty float64
+
+	// mu guards the tree, learning holds it for writing so readers see either none or all of a batch
+	mu sync.RWMutex
+	// shared replaces mu for classifiers whose tree is shared with other classifiers
+	shared *sync.RWMutex
+	// cacheMu guards the statistics derived from the tree, which readers build lazily and learning keeps up to date
+	cacheMu         sync.Mutex
+	unknown         *unknownCounts
+	previousUnknown *unknownCounts
 }
 
 // ErrInvalidSmoothingFactor is an error we throw when the smoothing factor provided is less than 0
 var ErrInvalidSmoothingFactor = errors.New("bayesian: invalid smoothing factor")
 
+// ErrInvalidCategory is an error we throw when learning a category that is out of range for the classifier
+var ErrInvalidCategory = errors.New("bayesian: invalid category")
+
+// ErrUntrained is an error we throw when scoring with a classifier that has not learned any words yet
+var ErrUntrained = errors.New("bayesian: classifier is untrained")
+
+// ErrDegenerateModel is an error we throw when a category has no probability mass to give to a word, which happens
+// when a category has not learned anything and the smoothing factor is 0
+var ErrDegenerateModel = errors.New("bayesian: degenerate model")
+
+// ErrNaN is an error we throw when a document is impossible in every category, so its scores cannot be normalized
+var ErrNaN = errors.New("bayesian: scores are not a number")
+
 func init() {
 	gob.Register(&classifier{})
 }
 
-func newClassifier(categories int, smoothingFactor float64) (*classifier, error) {
+func newClassifier(categories int, smoothingFactor float64, opts []Option) (*classifier, error) {
 	tree, err := radix.New(categories)
 	if err != nil {
 		return nil, err
@@ -52,112 +92,243 @@ func newClassifier(categories int, smoothingFactor float64) (*classifier, error)
 		return nil, ErrInvalidSmoothingFactor
 	}
 
-	return &classifier{Tree: tree, SmoothingFactor: smoothingFactor}, nil
+	c := &classifier{Tree: tree, SmoothingFactor: smoothingFactor}
+	if err := c.apply(opts); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// apply applies the options to the classifier in order
+func (c *classifier) apply(opts []Option) error {
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return err
+		}
+	}
+
+	// fuzzy matching is checked against the tree once every option is applied, whatever order they came in
+	if _, ok := c.Tree.(radix.FuzzyFinder); !ok && c.FuzzyEdits > 0 {
+		return ErrInvalidFuzzyMatching
+	}
+	return nil
 }
 
 // NewClassifier creates a new instance of a bayesian with n classes classifier
-func NewClassifier(categories int, smoothingFactor float64) (Classifier, error) {
-	return newClassifier(categories, smoothingFactor)
+func NewClassifier(categories int, smoothingFactor float64, opts ...Option) (Classifier, error) {
+	return newClassifier(categories, smoothingFactor, opts)
 }
 
 // NewBinaryClassifier creates a new bayesian classifier with two classes
-func NewBinaryClassifier(smoothingFactor float64) (BinaryClassifier, error) {
-	return newClassifier(2, smoothingFactor)
+func NewBinaryClassifier(smoothingFactor float64, opts ...Option) (BinaryClassifier, error) {
+	return newClassifier(2, smoothingFactor, opts)
 }
 
-func (c *classifier) getCategoryProbs(text string) []float64 {
-	if c.Tree.UniqueWords() == 0 {
-		return make([]float64, c.Tree.CategoryCount(), c.Tree.CategoryCount())
+// smoother returns the smoothing strategy of the classifier, additive smoothing by the smoothing factor by default
+func (c *classifier) smoother() Smoother {
+	if c.Smoother != nil {
+		return c.Smoother
+	}
+	return Additive{Alpha: c.SmoothingFactor}
+}
+
+// smoothingContext gathers the model wide statistics the smoother needs, which stay the same for every word
+func (c *classifier) smoothingContext() *SmoothingContext {
+	tree := c.Tree
+	// the statistics and every count of a document are read from one snapshot, however much is learned meanwhile
+	if snapshotter, ok := tree.(radix.Snapshotter); ok {
+		tree = snapshotter.Snapshot()
+	}
+
+	s := &SmoothingContext{UniqueWords: float64(tree.UniqueWords()), tree: tree}
+	if decayer, ok := tree.(radix.Decayer); ok {
+		s.at = decayer.Clock()
+		if now := time.Now(); c.AutoDecay && now.After(s.at) {
+			s.at = now
+		}
+		s.Totals = decayer.TotalsAt(s.at)
+	} else {
+		s.Totals = floatCounts(tree.GetTotals())
+	}
+
+	s.CategoryWords = make([]float64, len(s.Totals))
+	for i, total := range s.Totals {
+		// trees that do not count distinct words per category get the most the category could have seen
+		s.CategoryWords[i] = math.Min(total, s.UniqueWords)
+	}
+
+	if counter, ok := tree.(radix.TypeCounter); ok {
+		for i, types := range counter.CategoryUniqueWords() {
+			s.CategoryWords[i] = float64(types)
+		}
 	}
 
-	uniqueWords := float64(c.Tree.UniqueWords())
-	counts, seen := c.Tree.Find(text)
+	return s
+}
+
+// find returns the counts of a word, decayed counts are read at the time of the smoothing context
+func (c *classifier) find(text string, s *SmoothingContext) ([]float64, bool) {
+	if decayer, ok := s.tree.(radix.Decayer); ok {
+		return decayer.FindAt(text, s.at)
+	}
+
+	counts, seen := s.tree.Find(text)
+	return floatCounts(counts), seen
+}
+
+// getCategoryProbs computes the probability of a word in each category and reports whether the word is known, the
+// probabilities are nil when the word should be left out of the score
+func (c *classifier) getCategoryProbs(text string, s *SmoothingContext) ([]float64, bool, error) {
+	counts, seen := c.find(text, s)
 	if !seen {
+		counts = c.fuzzyCounts(text, s)
+	}
+
+	if !seen && counts == nil {
+		switch c.Unknown {
+		case UnknownIgnore:
+			return nil, false, nil
+		case UnknownHapax:
+			u := c.unknownCountsFor(s)
+			counts = scaled(floatCounts(u.hapax), unknownFade(u, s))
+		case UnknownShape:
+			u := c.unknownCountsFor(s)
+			counts = scaled(floatCounts(u.shapes[wordShape(text)]), unknownFade(u, s))
+		}
+	}
+
+	if counts == nil {
 		// if we have not seen this word, we try to smooth
-		counts = make([]int, c.Tree.CategoryCount(), c.Tree.CategoryCount())
+		counts = make([]float64, c.Tree.CategoryCount(), c.Tree.CategoryCount())
 	}
 
+	probs, err := c.smoothedProbs(counts, s)
+	return probs, seen, err
+}
+
+// smoothedProbs turns the counts of a word into its smoothed probability in each category
+func (c *classifier) smoothedProbs(counts []float64, s *SmoothingContext) ([]float64, error) {
+	// the background probability of the word is add one smoothed over the whole collection
+	wordTotal, total := 0.0, 0.0
+	for i := range counts {
+		wordTotal += counts[i]
+		total += s.Totals[i]
+	}
+	s.Background = (wordTotal + 1) / (total + s.UniqueWords)
+
+	smoother := c.smoother()
 	var probs []float64
 	for i := range counts {
-		numer := float64(counts[i]) + c.SmoothingFactor
-		denom := float64(c.Tree.GetTotals()[i]) + c.SmoothingFactor*uniqueWords
-		probs = append(probs, numer/denom)
+		prob := smoother.Prob(counts[i], i, s)
+		if math.IsNaN(prob) || math.IsInf(prob, 0) {
+			return nil, ErrDegenerateModel
+		}
+		probs = append(probs, prob)
 	}
 
-	return probs
+	return probs, nil
 }
 
-func (c *classifier) getPriors() []float64 {
-	sum := float64(0)
-	var priors []float64
-	for _, value := range c.Tree.GetTotals() {
-		total := float64(value)
-		sum += total
-		priors = append(priors, total)
+// floatCounts converts whole counts to the real valued counts smoothing works with, nil stays nil
+func floatCounts(counts []int) []float64 {
+	if counts == nil {
+		return nil
 	}
 
-	if sum != 0 {
-		for i := range priors {
-			priors[i] /= sum
-		}
+	floats := make([]float64, len(counts), len(counts))
+	for i, count := range counts {
+		floats[i] = float64(count)
 	}
-	return priors
+	return floats
 }
 
 // Scores computes the probability that a given document belongs to each of the categories we are tracking
-func (c *classifier) Scores(doc []string) ([]*big.Float, int, bool) {
-	var scores []*big.Float
-	priors := c.getPriors()
-	for _, prior := range priors {
-		scores = append(scores, big.NewFloat(prior))
-	}
-
-	// calculate the scores for each category
-	for _, word := range doc {
-		wordProbs := c.getCategoryProbs(word)
-		for i, prob := range wordProbs {
-			scores[i].Mul(scores[i], big.NewFloat(prob))
-		}
+func (c *classifier) Scores(doc []string) ([]*big.Float, int, bool, error) {
+	result, err := c.Score(doc)
+	if err != nil {
+		return nil, 0, false, err
 	}
+	return result.Scores, result.Best, result.Strict, nil
+}
 
-	sum := big.NewFloat(0.0)
-	for _, score := range scores {
-		sum.Add(sum, score)
+// Score computes the probability that a given document belongs to each category, along with how confident that
+// decision is and how many of the document's words the classifier knows
+func (c *classifier) Score(doc []string) (*ScoreResult, error) {
+	// a tree that can be snapshotted is scored against a snapshot without waiting for learning to finish, it publishes
+	// each batch whole
+	if _, ok := c.Tree.(radix.Snapshotter); ok {
+		return c.score(doc)
 	}
 
-	for i := range scores {
-		scores[i].Quo(scores[i], sum)
+	lock := c.lock()
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return c.score(doc)
+}
+
+// score is Score for callers that already hold the read lock
+func (c *classifier) score(doc []string) (*ScoreResult, error) {
+	if c.Tree.UniqueWords() == 0 {
+		return nil, ErrUntrained
 	}
 
-	idx, strict := findMax(scores)
-	return scores, idx, strict
+	return score(c.view(), doc)
 }
 
-// findMax finds the maximum of a set of scores and determines if that maximum is the only one (i.e. strict)
-func findMax(scores []*big.Float) (int, bool) {
-	idx := 0
-	strict := true
-	for i := 1; i < len(scores); i++ {
-		if scores[idx].Cmp(scores[i]) < 0 {
-			idx = i
-			strict = true
-		} else if scores[idx].Cmp(scores[i]) == 0 {
-			strict = false
-		}
+// lock returns the lock guarding the classifier's tree
+func (c *classifier) lock() *sync.RWMutex {
+	if c.shared != nil {
+		return c.shared
 	}
-	return idx, strict
+	return &c.mu
 }
 
-// Learn learns all of the words in a given document as members of a given category
-func (c *classifier) Learn(doc []string, category int) error {
-	for _, fragment := range doc {
-		err := c.Tree.Insert(fragment, category)
-		if err != nil {
-			return err
-		}
+// classifierView is the wordModel of a classifier, it holds the statistics that stay the same for a whole document.
+// It is only valid while the classifier's read lock is held.
+type classifierView struct {
+	c         *classifier
+	smoothing *SmoothingContext
+}
+
+func (c *classifier) view() *classifierView {
+	return &classifierView{c: c, smoothing: c.smoothingContext()}
+}
+
+func (v *classifierView) categoryCount() int {
+	return v.c.Tree.CategoryCount()
+}
+
+// logPriors computes the log of the share of all learned words that each category has
+func (v *classifierView) logPriors() []float64 {
+	sum := 0.0
+	for _, total := range v.smoothing.Totals {
+		sum += total
 	}
 
-	return nil
+	priors := make([]float64, len(v.smoothing.Totals))
+	for i, total := range v.smoothing.Totals {
+		priors[i] = math.Log(total / sum)
+	}
+	return priors
+}
+
+func (v *classifierView) wordLogProbs(word string, dst []float64) (bool, bool, error) {
+	probs, known, err := v.c.getCategoryProbs(word, v.smoothing)
+	if err != nil || probs == nil {
+		return known, false, err
+	}
+
+	for i, prob := range probs {
+		dst[i] = math.Log(prob)
+	}
+	return known, true, nil
+}
+
+// Learn learns all of the words in a given document as members of a given category, either every word is learned or
+// none of them are
+func (c *classifier) Learn(doc []string, category int) error {
+	return c.LearnBatch([]Document{{Words: doc, Category: category}})
 }
 
 // LearnPositive learns something for the binaryClassifier as positive
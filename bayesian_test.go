@@ -11,6 +11,24 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// learnDocs learns every document through learn, failing the test on each one it rejects
+func learnDocs(t testing.TB, docs []Document, learn func(words []string, category int) error) {
+	t.Helper()
+	for _, doc := range docs {
+		assert.NoError(t, learn(doc.Words, doc.Category))
+	}
+}
+
+// trainedClassifier creates a classifier with a smoothing factor of 1 and learns the documents
+func trainedClassifier(t testing.TB, categories int, docs []Document, opts ...Option) *classifier {
+	t.Helper()
+	c, err := newClassifier(categories, 1, opts)
+	assert.NoError(t, err)
+
+	learnDocs(t, docs, c.Learn)
+	return c
+}
+
 func TestBinaryClassifier(t *testing.T) {
 	c, err := NewBinaryClassifier(1)
 	assert.NoError(t, err)
@@ -26,21 +44,51 @@ func TestBinaryClassifier(t *testing.T) {
 		"ham", "spam", "apple", "cake", "app",
 		"dog", "rat", "bat", "rake", "dogged", "bothered"})
 
-	_, idx, _ := c.Scores([]string{"spam"})
+	_, idx, _, err := c.Scores([]string{"spam"})
+	assert.NoError(t, err)
 	assert.Equal(t, Positive, idx)
 
-	_, _, strict := c.Scores([]string{"apple"})
+	_, _, strict, err := c.Scores([]string{"apple"})
+	assert.NoError(t, err)
 	assert.False(t, strict)
 
-	_, idx, strict = c.Scores([]string{"dog"})
+	_, idx, strict, err = c.Scores([]string{"dog"})
+	assert.NoError(t, err)
 	assert.Equal(t, Negative, idx)
 	assert.True(t, strict)
 
-	_, _, strict = c.Scores([]string{"both"})
+	_, _, strict, err = c.Scores([]string{"both"})
+	assert.NoError(t, err)
 	assert.False(t, strict)
 
 }
 
+func TestScoresErrors(t *testing.T) {
+	c, err := NewClassifier(2, 0)
+	assert.NoError(t, err)
+
+	_, _, _, err = c.Scores([]string{"spam"})
+	assert.Equal(t, ErrUntrained, err)
+
+	assert.Equal(t, ErrInvalidCategory, c.Learn([]string{"spam"}, -1))
+	assert.Equal(t, ErrInvalidCategory, c.Learn([]string{"spam"}, 2))
+
+	// the second category has learned nothing, so without smoothing it has no probability mass at all
+	assert.NoError(t, c.Learn([]string{"spam"}, 0))
+	_, _, _, err = c.Scores([]string{"spam"})
+	assert.Equal(t, ErrDegenerateModel, err)
+
+	// a word neither category has seen is impossible everywhere without smoothing
+	assert.NoError(t, c.Learn([]string{"ham"}, 1))
+	_, _, _, err = c.Scores([]string{"eggs"})
+	assert.Equal(t, ErrNaN, err)
+
+	_, idx, strict, err := c.Scores([]string{"ham"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, idx)
+	assert.True(t, strict)
+}
+
 func TestEncodeDecode(t *testing.T) {
 	buf := new(bytes.Buffer)
 	enc := gob.NewEncoder(buf)
@@ -70,7 +118,8 @@ func TestEncodeDecode(t *testing.T) {
 	err = dec.Decode(&c2)
 	assert.NoError(t, err)
 
-	scores, idx, _ := c2.Scores([]string{"spam"})
+	scores, idx, _, err := c2.Scores([]string{"spam"})
+	assert.NoError(t, err)
 	assert.Equal(t, Positive, idx)
 	fmt.Printf("%#v\n", scores)
 
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// readMailbox calls fn with every message in a Maildir folder or an mbox file
+func readMailbox(path string, fn func(msg []byte) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return readMaildir(path, fn)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	return readMbox(f, fn)
+}
+
+// readMaildir reads every message file below a Maildir folder, skipping its tmp folder and dot files
+func readMaildir(dir string, fn func(msg []byte) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if d.Name() == "tmp" {
+				// messages in tmp are still being delivered
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		msg, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return fn(msg)
+	})
+}
+
+// readMbox splits an mbox stream on its "From " separator lines, undoing the ">From " quoting of the mboxrd format
+func readMbox(r io.Reader, fn func(msg []byte) error) error {
+	reader := bufio.NewReader(r)
+	var msg bytes.Buffer
+	started := false
+
+	flush := func() error {
+		if !started {
+			return nil
+		}
+		return fn(append([]byte(nil), msg.Bytes()...))
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if bytes.HasPrefix(line, []byte("From ")) {
+				if err := flush(); err != nil {
+					return err
+				}
+				msg.Reset()
+				started = true
+			} else if started {
+				if unquoted := bytes.TrimLeft(line, ">"); len(unquoted) < len(line) && bytes.HasPrefix(unquoted, []byte("From ")) {
+					line = line[1:]
+				}
+				msg.Write(line)
+			}
+		}
+
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Command bayes-mail is a bayesian spam filter for RFC 5322 mail
+//
+// Usage:
+//
+//	bayes-mail train  -model model.gob [-smoothing 1] -spam folder [-spam folder...] -ham folder [-ham folder...]
+//	bayes-mail filter -model model.gob [-threshold 0.9] < message > tagged-message
+//
+// Training folders are either Maildir directories or mbox files. Messages are tokenized from their headers, with each
+// word prefixed by its field name (subject:, from:, ...), and from their text parts after quoted-printable and base64
+// bodies are decoded.
+//
+// The filter mode reads one message on standard input and writes it to standard output with X-Spam-Score and
+// X-Spam-Flag headers added, so it can sit in a procmail pipeline:
+//
+//	:0fw
+//	| bayes-mail filter -model $HOME/.bayes-mail.gob
+//
+//	:0:
+//	* ^X-Spam-Flag: YES
+//	spam
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/LegoRemix/bayesian"
+)
+
+// labels are the names of the two categories, in the order of bayesian.Negative and bayesian.Positive
+var labels = []string{"ham", "spam"}
+
+// errUsage is returned when the command line cannot be understood, the usage has already been printed
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if err != errUsage {
+			fmt.Fprintln(os.Stderr, "bayes-mail:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "train":
+			return train(args[1:], stdout)
+		case "filter":
+			return filter(args[1:], stdin, stdout)
+		}
+	}
+
+	fmt.Fprintln(stderr, "usage: bayes-mail train -model model.gob -spam folder -ham folder")
+	fmt.Fprintln(stderr, "       bayes-mail filter -model model.gob [-threshold 0.9] < message")
+	return errUsage
+}
+
+// folders collects every occurrence of a repeated flag
+type folders []string
+
+func (f *folders) String() string { return strings.Join(*f, ",") }
+
+func (f *folders) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func train(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("train", flag.ContinueOnError)
+	modelPath := flags.String("model", "", "path the trained model is written to")
+	smoothing := flags.Float64("smoothing", 1, "additive smoothing factor")
+	var spam, ham folders
+	flags.Var(&spam, "spam", "Maildir folder or mbox file of spam, may be repeated")
+	flags.Var(&ham, "ham", "Maildir folder or mbox file of ham, may be repeated")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *modelPath == "" || len(spam) == 0 || len(ham) == 0 {
+		return errors.New("train: -model, -spam and -ham are required")
+	}
+
+	classifier, err := bayesian.NewBinaryClassifier(*smoothing)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	learn := func(kind string, learnFn func([]string) error, paths []string) error {
+		for _, path := range paths {
+			err := readMailbox(path, func(msg []byte) error {
+				tokens, err := tokenizeMessage(msg)
+				if err != nil {
+					// a single malformed message should not abort training on a whole folder
+					counts["skipped"]++
+					return nil
+				}
+				counts[kind]++
+				return learnFn(tokens)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := learn("spam", classifier.LearnPositive, spam); err != nil {
+		return err
+	}
+	if err := learn("ham", classifier.LearnNegative, ham); err != nil {
+		return err
+	}
+
+	model := &bayesian.Model{Labels: labels, Classifier: classifier.(bayesian.Classifier)}
+	if err := model.SaveFile(*modelPath); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "trained %d spam and %d ham messages, skipped %d\n",
+		counts["spam"], counts["ham"], counts["skipped"])
+	return err
+}
+
+func filter(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("filter", flag.ContinueOnError)
+	modelPath := flags.String("model", "", "path of the model to filter with")
+	threshold := flags.Float64("threshold", 0.9, "spam probability at or above which a message is flagged")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *modelPath == "" {
+		return errors.New("filter: -model is required")
+	}
+
+	model, err := bayesian.LoadModelFile(*modelPath)
+	if err != nil {
+		return err
+	}
+
+	classifier, ok := model.Classifier.(bayesian.BinaryClassifier)
+	if !ok || len(model.Labels) != 2 {
+		return errors.New("filter: model is not a binary classifier")
+	}
+
+	raw, err := io.ReadAll(stdin)
+	if err != nil {
+		return err
+	}
+
+	// a message we cannot parse or score is passed through unscored rather than lost
+	score := 0.0
+	if tokens, err := tokenizeMessage(raw); err == nil {
+		if scores, _, _, err := classifier.Scores(tokens); err == nil {
+			score, _ = scores[bayesian.Positive].Float64()
+		}
+	}
+
+	_, err = stdout.Write(tagMessage(raw, score, score >= *threshold))
+	return err
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', 4, 64)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+
+	"github.com/LegoRemix/bayesian"
+)
+
+// tokenizedHeaders are the header fields whose words are learned, each word is prefixed with the lower cased field
+// name so that "free" in a subject line is a different token from "free" in the body
+var tokenizedHeaders = []string{"Subject", "From", "Reply-To", "To", "Return-Path", "Content-Type", "X-Mailer"}
+
+// maxPartDepth bounds how deeply nested multipart messages are followed
+const maxPartDepth = 10
+
+// tokenizeMessage parses an RFC 5322 message and returns the tokens of its headers and its decoded text parts
+func tokenizeMessage(raw []byte) ([]string, error) {
+	// net/mail would read an mbox envelope line as a header
+	_, raw = splitEnvelope(raw)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []string
+	decoder := new(mime.WordDecoder)
+	for _, field := range tokenizedHeaders {
+		for _, value := range msg.Header[field] {
+			if decoded, err := decoder.DecodeHeader(value); err == nil {
+				value = decoded
+			}
+
+			prefix := strings.ToLower(field) + ":"
+			for _, word := range bayesian.Tokenize(value) {
+				tokens = append(tokens, prefix+word)
+			}
+		}
+	}
+
+	body, err := bodyTokens(msg.Header, msg.Body, 0)
+	if err != nil {
+		return nil, err
+	}
+	return append(tokens, body...), nil
+}
+
+// header is the part of a MIME header we need, satisfied by both mail.Header and textproto.MIMEHeader
+type header interface {
+	Get(key string) string
+}
+
+// bodyTokens decodes a body according to its content headers and tokenizes the text it contains
+func bodyTokens(h header, body io.Reader, depth int) ([]string, error) {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		// messages without a (valid) content type are plain text
+		mediaType, params = "text/plain", nil
+	}
+
+	body = decodeTransfer(h.Get("Content-Transfer-Encoding"), body)
+
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" && depth < maxPartDepth {
+		var tokens []string
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return tokens, nil
+			}
+			if err != nil {
+				// a truncated multipart body still has useful tokens in the parts we could read
+				return tokens, nil
+			}
+
+			partTokens, err := bodyTokens(part.Header, part, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, partTokens...)
+		}
+	}
+
+	if !strings.HasPrefix(mediaType, "text/") {
+		// we do not look inside attachments, but their type is a useful signal
+		return []string{"attachment:" + mediaType}, nil
+	}
+
+	text, err := io.ReadAll(body)
+	if err != nil && len(text) == 0 {
+		return nil, err
+	}
+
+	if mediaType == "text/html" {
+		return append(bayesian.Tokenize(stripTags(string(text))), "content:html"), nil
+	}
+	return bayesian.Tokenize(string(text)), nil
+}
+
+// decodeTransfer undoes a Content-Transfer-Encoding
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	default:
+		return body
+	}
+}
+
+// stripTags removes HTML markup, leaving the text between the tags
+func stripTags(html string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range html {
+		switch {
+		case r == '<':
+			inTag = true
+			b.WriteRune(' ')
+		case r == '>':
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// spamHeaders are the header fields we add, any copies already in a message are removed so senders cannot forge them
+var spamHeaders = []string{"X-Spam-Score", "X-Spam-Flag"}
+
+// tagMessage returns the message with X-Spam-Score and X-Spam-Flag headers prepended to its header block, after the
+// mbox envelope line when there is one, the headers end their lines the same way as the message's own
+func tagMessage(raw []byte, score float64, spam bool) []byte {
+	flag := "NO"
+	if spam {
+		flag = "YES"
+	}
+
+	envelope, raw := splitEnvelope(raw)
+	newline := lineEnding(raw)
+	var out bytes.Buffer
+	out.Write(envelope)
+	out.WriteString("X-Spam-Score: " + formatScore(score) + newline)
+	out.WriteString("X-Spam-Flag: " + flag + newline)
+
+	rest := raw
+	dropping := false
+	for len(rest) > 0 {
+		end := bytes.IndexByte(rest, '\n') + 1
+		if end == 0 {
+			end = len(rest)
+		}
+		line := rest[:end]
+
+		if len(bytes.TrimRight(line, "\r\n")) == 0 {
+			// the blank line ends the header block, the body is copied untouched
+			out.Write(rest)
+			return out.Bytes()
+		}
+
+		continuation := line[0] == ' ' || line[0] == '\t'
+		if !continuation {
+			dropping = isSpamHeader(line)
+		}
+
+		if !dropping {
+			out.Write(line)
+		}
+		rest = rest[end:]
+	}
+
+	return out.Bytes()
+}
+
+// splitEnvelope splits the "From " envelope line a message kept from an mbox file starts with from the message
+func splitEnvelope(raw []byte) (envelope, rest []byte) {
+	if !bytes.HasPrefix(raw, []byte("From ")) {
+		return nil, raw
+	}
+
+	end := bytes.IndexByte(raw, '\n') + 1
+	if end == 0 {
+		end = len(raw)
+	}
+	return raw[:end], raw[end:]
+}
+
+// lineEnding returns the line ending of the first line of a message, messages from mbox files and procmail usually
+// end their lines with a bare LF, CRLF is what RFC 5322 asks for when there is no line to go by
+func lineEnding(raw []byte) string {
+	end := bytes.IndexByte(raw, '\n')
+	if end == 0 || end > 0 && raw[end-1] != '\r' {
+		return "\n"
+	}
+	return "\r\n"
+}
+
+func isSpamHeader(line []byte) bool {
+	colon := bytes.IndexByte(line, ':')
+	if colon < 0 {
+		return false
+	}
+
+	name := strings.TrimSpace(string(line[:colon]))
+	for _, field := range spamHeaders {
+		if strings.EqualFold(name, field) {
+			return true
+		}
+	}
+	return false
+}
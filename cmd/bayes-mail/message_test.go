@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const multipartMessage = "From: \"Prize Desk\" <winner@lottery.example>\r\n" +
+	"To: you@example.com\r\n" +
+	"Subject: =?utf-8?q?You_are_a_WINNER?=\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"b1\"\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Claim your fr=\r\nee prize =3D money\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"PGI+Q2FzaW5vPC9iPiBib251cw==\r\n" +
+	"--b1\r\n" +
+	"Content-Type: application/pdf\r\n" +
+	"\r\n" +
+	"%PDF-1.4\r\n" +
+	"--b1--\r\n"
+
+func TestTokenizeMessage(t *testing.T) {
+	tokens, err := tokenizeMessage([]byte(multipartMessage))
+	assert.NoError(t, err)
+
+	for _, want := range []string{"subject:winner", "from:lottery", "to:you", "free", "prize", "money",
+		"casino", "bonus", "content:html", "attachment:application/pdf"} {
+		assert.Contains(t, tokens, want)
+	}
+	assert.NotContains(t, tokens, "fr")
+	assert.NotContains(t, tokens, "b")
+
+	tokens, err = tokenizeMessage([]byte("Subject: hi\n\nplain body"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"subject:hi", "plain", "body"}, tokens)
+
+	// the envelope line of a message kept from an mbox file is not a header
+	tokens, err = tokenizeMessage([]byte("From a@example.com Mon Jan  1 00:00:00 2024\nSubject: hi\n\nplain body"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"subject:hi", "plain", "body"}, tokens)
+
+	_, err = tokenizeMessage([]byte("not a message"))
+	assert.Error(t, err)
+}
+
+func TestTagMessage(t *testing.T) {
+	raw := "X-Spam-Flag: NO\r\nSubject: hello\r\nX-Spam-Score:\r\n 0.0\r\n\r\nX-Spam-Flag: body text\r\n"
+	tagged := string(tagMessage([]byte(raw), 0.97, true))
+	assert.Equal(t, "X-Spam-Score: 0.9700\r\nX-Spam-Flag: YES\r\nSubject: hello\r\n\r\nX-Spam-Flag: body text\r\n", tagged)
+
+	// mbox and procmail messages end their lines with a bare LF
+	raw = "Subject: hello\nX-Spam-Flag: YES\n\nbody text\r\n"
+	tagged = string(tagMessage([]byte(raw), 0.12, false))
+	assert.Equal(t, "X-Spam-Score: 0.1200\nX-Spam-Flag: NO\nSubject: hello\n\nbody text\r\n", tagged)
+
+	// a message without a line break gets CRLF
+	tagged = string(tagMessage([]byte("Subject: hello"), 0.12, false))
+	assert.Equal(t, "X-Spam-Score: 0.1200\r\nX-Spam-Flag: NO\r\nSubject: hello", tagged)
+
+	// the envelope line of a message kept from an mbox file stays first
+	raw = "From a@example.com Mon Jan  1 00:00:00 2024\nX-Spam-Flag: YES\nSubject: hello\n\nbody text\n"
+	tagged = string(tagMessage([]byte(raw), 0.12, false))
+	assert.Equal(t, "From a@example.com Mon Jan  1 00:00:00 2024\nX-Spam-Score: 0.1200\nX-Spam-Flag: NO\n"+
+		"Subject: hello\n\nbody text\n", tagged)
+}
+
+func TestReadMbox(t *testing.T) {
+	mbox := "From a@example.com Mon Jan  1 00:00:00 2024\nSubject: one\n\n>From the start\n" +
+		"From b@example.com Mon Jan  1 00:00:00 2024\nSubject: two\n\nbody\n"
+
+	var msgs []string
+	assert.NoError(t, readMbox(strings.NewReader(mbox), func(msg []byte) error {
+		msgs = append(msgs, string(msg))
+		return nil
+	}))
+	assert.Equal(t, []string{"Subject: one\n\nFrom the start\n", "Subject: two\n\nbody\n"}, msgs)
+}
+
+func TestTrainAndFilter(t *testing.T) {
+	dir := t.TempDir()
+	for name, msg := range map[string]string{
+		"spam/cur/1": "Subject: free prize\n\nclaim your lottery winnings",
+		"spam/new/2": "Subject: casino bonus\n\nfree money",
+		"spam/tmp/3": "Subject: meeting\n\nstill being delivered",
+		"ham/cur/1":  "Subject: meeting notes\n\nthe quarterly report",
+		"ham/cur/2":  "Subject: lunch\n\nsee you at noon",
+	} {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(msg), 0o644))
+	}
+
+	model := filepath.Join(dir, "model.gob")
+	out := new(bytes.Buffer)
+	err := run([]string{"train", "-model", model, "-spam", filepath.Join(dir, "spam"), "-ham", filepath.Join(dir, "ham")},
+		nil, out, out)
+	assert.NoError(t, err)
+	assert.Equal(t, "trained 2 spam and 2 ham messages, skipped 0\n", out.String())
+
+	out.Reset()
+	msg := "Subject: free casino prize\n\nclaim it\n"
+	assert.NoError(t, run([]string{"filter", "-model", model}, strings.NewReader(msg), out, out))
+	assert.True(t, strings.HasPrefix(out.String(), "X-Spam-Score: "), out.String())
+	assert.Contains(t, out.String(), "X-Spam-Flag: YES\n"+msg)
+	assert.NotContains(t, out.String(), "\r")
+
+	out.Reset()
+	assert.NoError(t, run([]string{"filter", "-model", model}, strings.NewReader("Subject: meeting\n\nlunch report\n"), out, out))
+	assert.Contains(t, out.String(), "X-Spam-Flag: NO\nSubject: meeting\n")
+
+	assert.Equal(t, errUsage, run(nil, nil, out, out))
+}
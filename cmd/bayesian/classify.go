@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/LegoRemix/bayesian"
+)
+
+// classification is the JSON form of the result for a single document
+type classification struct {
+	Document  string             `json:"document"`
+	Label     string             `json:"label"`
+	Posterior float64            `json:"posterior"`
+	Strict    bool               `json:"strict"`
+	Margin    float64            `json:"margin"`
+	Entropy   float64            `json:"entropy"`
+	Scores    map[string]float64 `json:"scores"`
+	Unknown   int                `json:"unknown_tokens"`
+}
+
+func classify(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("classify", flag.ContinueOnError)
+	modelPath := flags.String("model", "", "path of the model to classify with")
+	format := flags.String("format", "text", "output format, text or json")
+	lines := flags.Bool("lines", false, "treat every input line as a separate document")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *modelPath == "" {
+		return errors.New("classify: -model is required")
+	}
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("classify: unknown format %q", *format)
+	}
+
+	model, err := bayesian.LoadModelFile(*modelPath)
+	if err != nil {
+		return err
+	}
+
+	names := flags.Args()
+	files, err := inputs(names, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeAll(files)
+
+	enc := json.NewEncoder(stdout)
+	for i, f := range files {
+		name := "-"
+		if i < len(names) {
+			name = names[i]
+		}
+
+		contents, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+
+		docs := []string{string(contents)}
+		if *lines {
+			docs = strings.Split(strings.TrimRight(string(contents), "\n"), "\n")
+		}
+
+		for n, doc := range docs {
+			result, err := classifyOne(model, doc)
+			if err != nil {
+				return err
+			}
+			result.Document = name
+			if *lines {
+				result.Document = fmt.Sprintf("%s:%d", name, n+1)
+			}
+
+			if *format == "json" {
+				err = enc.Encode(result)
+			} else {
+				_, err = fmt.Fprintf(stdout, "%s\t%s\t%.6f\n", result.Document, result.Label, result.Posterior)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// classifyOne scores a single document
+func classifyOne(model *bayesian.Model, text string) (classification, error) {
+	scored, err := model.Classifier.Score(bayesian.Tokenize(text))
+	if err != nil {
+		return classification{}, err
+	}
+
+	result := classification{
+		Label:     model.Label(scored.Best),
+		Strict:    scored.Strict,
+		Margin:    scored.Margin,
+		Entropy:   scored.Entropy,
+		Posterior: scored.Posteriors[scored.Best],
+		Scores:    make(map[string]float64),
+		Unknown:   scored.Unknown,
+	}
+	for i, posterior := range scored.Posteriors {
+		result.Scores[model.Label(i)] = posterior
+	}
+
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/LegoRemix/bayesian"
+)
+
+func eval(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	modelPath := flags.String("model", "", "path of the model to evaluate")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *modelPath == "" {
+		return errors.New("eval: -model is required")
+	}
+
+	model, err := bayesian.LoadModelFile(*modelPath)
+	if err != nil {
+		return err
+	}
+
+	lines, err := readLabeled(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+
+	docs := make([]bayesian.Document, 0, len(lines))
+	for _, line := range lines {
+		category, ok := model.Category(line.Label)
+		if !ok {
+			return fmt.Errorf("eval: label %q is not in the model", line.Label)
+		}
+		docs = append(docs, bayesian.Document{Category: category, Words: bayesian.Tokenize(line.Text)})
+	}
+
+	e, err := bayesian.Evaluate(model.Classifier, len(model.Labels), docs)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "accuracy: %.4f (%d/%d)\n\n", e.Accuracy(), e.Correct, e.Total)
+
+	// the confusion matrix has the actual labels as rows and the predicted labels as columns
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "actual \\ predicted\t")
+	for _, label := range model.Labels {
+		fmt.Fprintf(w, "%s\t", label)
+	}
+	fmt.Fprintln(w)
+
+	for i, row := range e.Confusion {
+		fmt.Fprintf(w, "%s\t", model.Labels[i])
+		for _, count := range row {
+			fmt.Fprintf(w, "%d\t", count)
+		}
+		fmt.Fprintln(w)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/LegoRemix/bayesian"
+)
+
+func inspect(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	modelPath := flags.String("model", "", "path of the model to inspect")
+	top := flags.Int("top", 10, "number of top words to show per category")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *modelPath == "" {
+		return errors.New("inspect: -model is required")
+	}
+
+	model, err := bayesian.LoadModelFile(*modelPath)
+	if err != nil {
+		return err
+	}
+
+	inspector, ok := model.Classifier.(bayesian.Inspector)
+	if !ok {
+		return errors.New("inspect: this model cannot be inspected")
+	}
+
+	stats := inspector.Stats()
+	fmt.Fprintf(stdout, "categories: %d\n", len(model.Labels))
+	fmt.Fprintf(stdout, "vocabulary: %d\n", stats.UniqueWords)
+	fmt.Fprintf(stdout, "smoothing:  %g\n", stats.SmoothingFactor)
+	fmt.Fprintf(stdout, "smoother:   %v\n", stats.Smoother)
+	fmt.Fprintf(stdout, "unknown:    %v\n", stats.Unknown)
+
+	for i, label := range model.Labels {
+		fmt.Fprintf(stdout, "\n%s: %d words\n", label, stats.CategoryTotals[i])
+		for _, word := range inspector.TopWords(i, *top) {
+			fmt.Fprintf(stdout, "  %-20s %d\n", word.Word, word.Counts[i])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+// Command bayesian trains, applies, evaluates and inspects bayesian classifier models
+//
+// Usage:
+//
+//	bayesian train    -model model.gob [-smoothing 1] [-smoother spec] [-unknown policy] [-dir corpus | labeled files...]
+//	bayesian classify -model model.gob [-format text|json] [-lines] [files...]
+//	bayesian eval     -model model.gob [labeled files...]
+//	bayesian inspect  -model model.gob [-top 10]
+//	bayesian serve    -model model.gob [-addr :8080] [-no-learn] [-max-body 1048576] [-watch 5s]
+//
+// Labeled files hold one document per line, the label comes first and is separated from the text by a tab.
+// When no files are given the documents are read from standard input.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// command is a single subcommand of the tool
+type command struct {
+	summary string
+	run     func(args []string, stdin io.Reader, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"train":    {"build a model from labeled documents and save it", train},
+	"classify": {"print the label and posterior of documents", classify},
+	"eval":     {"print the accuracy and confusion matrix on labeled documents", eval},
+	"inspect":  {"show category totals, vocabulary size, smoothing and top words", inspect},
+	"serve":    {"serve a model over HTTP, reloading it when it changes or on SIGHUP", serve},
+}
+
+// errUsage is returned when the command line cannot be understood, the usage has already been printed
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if err != errUsage {
+			fmt.Fprintln(os.Stderr, "bayesian:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run dispatches to the subcommand named by the first argument
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 {
+		usage(stderr)
+		return errUsage
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(stderr)
+		return errUsage
+	}
+
+	return cmd.run(args[1:], stdin, stdout)
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: bayesian <command> [flags]")
+	fmt.Fprintln(w)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-9s %s\n", name, commands[name].summary)
+	}
+}
+
+// inputs opens the named files, or returns stdin when no files were named
+func inputs(names []string, stdin io.Reader) ([]io.ReadCloser, error) {
+	if len(names) == 0 {
+		return []io.ReadCloser{io.NopCloser(stdin)}, nil
+	}
+
+	var files []io.ReadCloser
+	for _, name := range names {
+		if name == "-" {
+			files = append(files, io.NopCloser(stdin))
+			continue
+		}
+
+		f, err := os.Open(name)
+		if err != nil {
+			closeAll(files)
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+func closeAll(files []io.ReadCloser) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
+
+// labeled is a single line of a labeled file
+type labeled struct {
+	Label string
+	Text  string
+}
+
+// readLabeled reads every labeled document from the named files
+func readLabeled(names []string, stdin io.Reader) ([]labeled, error) {
+	files, err := inputs(names, stdin)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAll(files)
+
+	var docs []labeled
+	for _, f := range files {
+		contents, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+
+		for n, line := range strings.Split(string(contents), "\n") {
+			line = strings.TrimRight(line, "\r")
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			tab := strings.IndexByte(line, '\t')
+			if tab <= 0 {
+				return nil, fmt.Errorf("line %d: expected a label and a tab before the text", n+1)
+			}
+			docs = append(docs, labeled{Label: line[:tab], Text: line[tab+1:]})
+		}
+	}
+
+	return docs, nil
+}
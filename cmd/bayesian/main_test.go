@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const trainingSet = "spam\tWin a free lottery prize now\n" +
+	"ham\tThe quarterly report is attached\n" +
+	"spam\tFree prize, claim your winnings\n" +
+	"ham\tLunch meeting moved to noon\n"
+
+func runCommand(t *testing.T, stdin string, args ...string) (string, error) {
+	out := new(bytes.Buffer)
+	err := run(args, strings.NewReader(stdin), out, new(bytes.Buffer))
+	return out.String(), err
+}
+
+func TestTrainClassifyEval(t *testing.T) {
+	dir := t.TempDir()
+	model := filepath.Join(dir, "model.gob")
+
+	out, err := runCommand(t, trainingSet, "train", "-model", model)
+	assert.NoError(t, err)
+	assert.Equal(t, "trained 4 documents in 2 categories\n", out)
+
+	out, err = runCommand(t, "claim your free prize\nmeeting report\n", "classify", "-model", model, "-lines")
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "-:1\tspam\t"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "-:2\tham\t"), lines[1])
+
+	doc := filepath.Join(dir, "doc.txt")
+	assert.NoError(t, os.WriteFile(doc, []byte("lottery winnings jackpot"), 0o644))
+	out, err = runCommand(t, "", "classify", "-model", model, "-format", "json", doc)
+	assert.NoError(t, err)
+	var result classification
+	assert.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, doc, result.Document)
+	assert.Equal(t, "spam", result.Label)
+	assert.Equal(t, 1, result.Unknown)
+	assert.InDelta(t, 1, result.Scores["ham"]+result.Scores["spam"], 1e-9)
+
+	out, err = runCommand(t, "spam\tfree lottery\nham\tquarterly lunch\n", "eval", "-model", model)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "accuracy: 1.0000 (2/2)")
+
+	_, err = runCommand(t, "eggs\tfree lottery\n", "eval", "-model", model)
+	assert.Error(t, err)
+
+	out, err = runCommand(t, "", "inspect", "-model", model, "-top", "1")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "categories: 2")
+	assert.Contains(t, out, "smoothing:  1")
+	assert.Contains(t, out, "smoother:   additive(alpha=1)")
+	assert.Contains(t, out, "free")
+}
+
+func TestTrainWithSmoother(t *testing.T) {
+	model := filepath.Join(t.TempDir(), "model.gob")
+	_, err := runCommand(t, trainingSet, "train", "-model", model, "-smoother", "dirichlet:50")
+	assert.NoError(t, err)
+
+	out, err := runCommand(t, "", "inspect", "-model", model)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "smoother:   dirichlet(mu=50)")
+
+	_, err = runCommand(t, trainingSet, "train", "-model", model, "-smoother", "dirichlet:-1")
+	assert.Error(t, err)
+
+	_, err = runCommand(t, trainingSet, "train", "-model", model, "-unknown", "ignore")
+	assert.NoError(t, err)
+	out, err = runCommand(t, "", "inspect", "-model", model)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "unknown:    ignore")
+
+	_, err = runCommand(t, trainingSet, "train", "-model", model, "-unknown", "guess")
+	assert.Error(t, err)
+}
+
+func TestTrainFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for name, text := range map[string]string{"spam/1": "free prize", "ham/1": "team lunch"} {
+		path := filepath.Join(dir, "corpus", name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(text), 0o644))
+	}
+
+	model := filepath.Join(dir, "model.gob")
+	out, err := runCommand(t, "", "train", "-model", model, "-dir", filepath.Join(dir, "corpus"))
+	assert.NoError(t, err)
+	assert.Equal(t, "trained 2 documents in 2 categories\n", out)
+}
+
+func TestUsage(t *testing.T) {
+	_, err := runCommand(t, "")
+	assert.Equal(t, errUsage, err)
+	_, err = runCommand(t, "", "frobnicate")
+	assert.Equal(t, errUsage, err)
+	_, err = runCommand(t, "", "train")
+	assert.Error(t, err)
+	_, err = runCommand(t, "bad line without tab\n", "train", "-model", filepath.Join(t.TempDir(), "m"))
+	assert.Error(t, err)
+}
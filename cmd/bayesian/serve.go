@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/LegoRemix/bayesian/server"
+)
+
+func serve(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	modelPath := flags.String("model", "", "path of the model to serve")
+	addr := flags.String("addr", ":8080", "address to listen on")
+	noLearn := flags.Bool("no-learn", false, "disable the /learn endpoint")
+	maxBody := flags.Int64("max-body", server.DefaultMaxBodyBytes, "largest request body accepted, in bytes")
+	watch := flags.Duration("watch", 5*time.Second, "how often to check the model file for changes, 0 disables watching")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *modelPath == "" {
+		return errors.New("serve: -model is required")
+	}
+
+	s, err := server.Open(*modelPath, server.Options{DisableLearn: *noLearn, MaxBodyBytes: *maxBody})
+	if err != nil {
+		return err
+	}
+
+	logger := log.New(stdout, "", log.LstdFlags)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if *watch > 0 {
+		go s.Watch(ctx, *watch, func(err error) { logger.Printf("reload: %v", err) })
+	}
+
+	// SIGHUP reloads the model, SIGINT and SIGTERM shut the server down after in flight requests finish
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	httpServer := &http.Server{Addr: *addr, Handler: s}
+	errs := make(chan error, 1)
+	go func() { errs <- httpServer.ListenAndServe() }()
+	logger.Printf("serving %s on %s", *modelPath, *addr)
+
+	for {
+		select {
+		case err := <-errs:
+			return err
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				if err := s.Reload(); err != nil {
+					logger.Printf("reload: %v", err)
+				} else {
+					logger.Printf("reloaded %s", *modelPath)
+				}
+				continue
+			}
+
+			shutdown, done := context.WithTimeout(context.Background(), 10*time.Second)
+			err := httpServer.Shutdown(shutdown)
+			done()
+			if err != nil {
+				return fmt.Errorf("serve: %v", err)
+			}
+			return nil
+		}
+	}
+}
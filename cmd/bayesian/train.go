@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/LegoRemix/bayesian"
+)
+
+func train(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("train", flag.ContinueOnError)
+	modelPath := flags.String("model", "", "path the trained model is written to")
+	smoothing := flags.Float64("smoothing", 1, "additive smoothing factor")
+	smoother := flags.String("smoother", "", "smoothing strategy, such as dirichlet:2000 or witten-bell, instead of additive")
+	unknown := flags.String("unknown", "smooth", "how unseen words are scored: smooth, ignore, hapax or shape")
+	dir := flags.String("dir", "", "train from a directory with one folder per category instead of labeled files")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *modelPath == "" {
+		return errors.New("train: -model is required")
+	}
+
+	policy, err := bayesian.ParseUnknownPolicy(*unknown)
+	if err != nil {
+		return err
+	}
+
+	opts := []bayesian.Option{bayesian.WithUnknownPolicy(policy)}
+	if *smoother != "" {
+		s, err := bayesian.ParseSmoother(*smoother)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, bayesian.WithSmoother(s))
+	}
+
+	var model *bayesian.Model
+	var documents int
+	if *dir != "" {
+		corpus, err := bayesian.LoadCorpus(os.DirFS(*dir), ".", bayesian.CorpusOptions{})
+		if err != nil {
+			return err
+		}
+
+		c, err := corpus.NewClassifier(*smoothing, opts...)
+		if err != nil {
+			return err
+		}
+		model = &bayesian.Model{Labels: corpus.Categories, Classifier: c}
+		documents = len(corpus.Train)
+	} else {
+		docs, err := readLabeled(flags.Args(), stdin)
+		if err != nil {
+			return err
+		}
+
+		model, err = bayesian.NewModel(labelsOf(docs), *smoothing, opts...)
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			category, _ := model.Category(doc.Label)
+			if err := model.Classifier.Learn(bayesian.Tokenize(doc.Text), category); err != nil {
+				return err
+			}
+		}
+		documents = len(docs)
+	}
+
+	if err := model.SaveFile(*modelPath); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "trained %d documents in %d categories\n", documents, len(model.Labels))
+	return err
+}
+
+// labelsOf returns the distinct labels of a set of documents in sorted order
+func labelsOf(docs []labeled) []string {
+	seen := make(map[string]bool)
+	var labels []string
+	for _, doc := range docs {
+		if !seen[doc.Label] {
+			seen[doc.Label] = true
+			labels = append(labels, doc.Label)
+		}
+	}
+
+	sort.Strings(labels)
+	return labels
+}
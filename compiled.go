@@ -0,0 +1,282 @@
+package bayesian
+
+import (
+	"encoding/gob"
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// ErrReadOnly is an error we throw when trying to learn with a compiled classifier
+var ErrReadOnly = errors.New("bayesian: classifier is read only")
+
+// ErrNotCompilable is an error we throw when asked to compile a classifier this package cannot freeze
+var ErrNotCompilable = errors.New("bayesian: classifier cannot be compiled")
+
+// ErrModelTooLarge is an error we throw when the words of a compiled model take more bytes than its 32 bit offsets
+// can address
+var ErrModelTooLarge = errors.New("bayesian: model is too large")
+
+// maxTableBytes is how many bytes of words a table can hold, it is a variable so that tests can lower it
+var maxTableBytes uint64 = math.MaxUint32
+
+// wordTable is a sorted string table, the words are concatenated into one byte slice and found by binary search over
+// their offsets, and each has a row of per category log probabilities
+type wordTable struct {
+	Words      []byte
+	Offsets    []uint32
+	LogProbs   []float64
+	Categories int
+}
+
+// Len returns the number of words in the table
+func (t *wordTable) Len() int {
+	if len(t.Offsets) == 0 {
+		return 0
+	}
+	return len(t.Offsets) - 1
+}
+
+// word returns the i-th word without copying it
+func (t *wordTable) word(i int) []byte {
+	return t.Words[t.Offsets[i]:t.Offsets[i+1]]
+}
+
+// find returns the row of a word, or -1 if the table does not hold it
+func (t *wordTable) find(needle string) int {
+	n := t.Len()
+	idx := sort.Search(n, func(i int) bool { return string(t.word(i)) >= needle })
+	if idx < n && string(t.word(idx)) == needle {
+		return idx
+	}
+	return -1
+}
+
+// row returns the log probabilities of the i-th word
+func (t *wordTable) row(i int) []float64 {
+	return t.LogProbs[i*t.Categories : (i+1)*t.Categories]
+}
+
+// add appends a word, words must be added in sorted order. It fails once the words no longer fit the offsets.
+func (t *wordTable) add(word string, logProbs []float64) error {
+	if uint64(len(t.Words))+uint64(len(word)) > maxTableBytes {
+		return ErrModelTooLarge
+	}
+
+	if len(t.Offsets) == 0 {
+		t.Offsets = append(t.Offsets, 0)
+	}
+	t.Words = append(t.Words, word...)
+	t.Offsets = append(t.Offsets, uint32(len(t.Words)))
+	t.LogProbs = append(t.LogProbs, logProbs...)
+	return nil
+}
+
+// compiled is an immutable classifier whose per word log probabilities were computed ahead of time
+type compiled struct {
+	readOnly
+	Table     wordTable
+	LogPriors []float64
+	Unknown   UnknownPolicy
+	// UnknownLogProbs is what an unseen word scores under the smooth and hapax policies, and under the shape policy
+	// when its shape is not in Shapes
+	UnknownLogProbs []float64
+	Shapes          wordTable
+	// Rows maps the ID the tree gave each word to the word's row, -1 for IDs that are not words. It is nil when the tree
+	// did not give IDs, and the rows are the IDs.
+	Rows []int32
+	// IDs holds the ID the tree gave the word of each row
+	IDs []uint32
+}
+
+func init() {
+	gob.Register(&compiled{})
+}
+
+// Compile freezes a classifier into a flat, read only form for serving. Every word's log probability in every
+// category is computed once up front, so scoring is a binary search and an addition per word. The compiled
+// classifier scores exactly like the original did at the time it was compiled, and rejects learning with ErrReadOnly.
+func Compile(c Classifier) (Classifier, error) {
+	cl, ok := c.(*classifier)
+	// the words fuzzy matching would find are not known until they are scored
+	if !ok || cl.FuzzyEdits > 0 {
+		return nil, ErrNotCompilable
+	}
+
+	// decayed counts are not whole numbers and keep fading after the compile
+	if _, ok := cl.Tree.(radix.Decayer); ok {
+		return nil, ErrNotCompilable
+	}
+
+	lock := cl.lock()
+	lock.RLock()
+	defer lock.RUnlock()
+
+	walker, ok := cl.Tree.(radix.Walker)
+	if !ok {
+		return nil, ErrNotCompilable
+	}
+
+	if cl.Tree.UniqueWords() == 0 {
+		return nil, ErrUntrained
+	}
+
+	v := cl.view()
+	n := cl.Tree.CategoryCount()
+	out := &compiled{
+		Table:     wordTable{Categories: n},
+		LogPriors: v.logPriors(),
+		Unknown:   cl.Unknown,
+		Shapes:    wordTable{Categories: n},
+	}
+
+	vocabulary, hasIDs := cl.Tree.(radix.Vocabulary)
+	var err error
+	walker.Walk(func(word string, counts []int) bool {
+		var logProbs []float64
+		logProbs, err = cl.smoothedLogProbs(counts, v.smoothing)
+		if err != nil {
+			return false
+		}
+		if err = out.Table.add(word, logProbs); err != nil {
+			return false
+		}
+
+		if hasIDs {
+			id, _ := vocabulary.ID(word)
+			out.IDs = append(out.IDs, id)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// keeping the tree's IDs means documents resolved to IDs once stay valid for every later compile of the tree
+	if hasIDs {
+		out.Rows = rowsByID(out.IDs)
+	}
+
+	unknown := make([]int, n)
+	switch cl.Unknown {
+	case UnknownHapax:
+		unknown = cl.unknownCountsFor(v.smoothing).hapax
+	case UnknownShape:
+		shapes := cl.unknownCountsFor(v.smoothing).shapes
+		names := make([]string, 0, len(shapes))
+		for shape := range shapes {
+			names = append(names, shape)
+		}
+		sort.Strings(names)
+
+		for _, shape := range names {
+			logProbs, err := cl.smoothedLogProbs(shapes[shape], v.smoothing)
+			if err != nil {
+				return nil, err
+			}
+			if err := out.Shapes.add(shape, logProbs); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if cl.Unknown != UnknownIgnore {
+		out.UnknownLogProbs, err = cl.smoothedLogProbs(unknown, v.smoothing)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// smoothedLogProbs is smoothedProbs in log space
+func (c *classifier) smoothedLogProbs(counts []int, s *SmoothingContext) ([]float64, error) {
+	if counts == nil {
+		counts = make([]int, c.Tree.CategoryCount())
+	}
+
+	probs, err := c.smoothedProbs(floatCounts(counts), s)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, prob := range probs {
+		probs[i] = math.Log(prob)
+	}
+	return probs, nil
+}
+
+func (m *compiled) categoryCount() int {
+	return m.Table.Categories
+}
+
+func (m *compiled) logPriors() []float64 {
+	return m.LogPriors
+}
+
+func (m *compiled) wordLogProbs(word string, dst []float64) (bool, bool, error) {
+	if i := m.Table.find(word); i >= 0 {
+		copy(dst, m.Table.row(i))
+		return true, true, nil
+	}
+
+	if m.Unknown == UnknownIgnore {
+		return false, false, nil
+	}
+
+	if m.Unknown == UnknownShape {
+		if i := m.Shapes.find(wordShape(word)); i >= 0 {
+			copy(dst, m.Shapes.row(i))
+			return false, true, nil
+		}
+	}
+
+	copy(dst, m.UnknownLogProbs)
+	return false, true, nil
+}
+
+// Scores computes the probability that a given document belongs to each of the categories
+func (m *compiled) Scores(doc []string) ([]*big.Float, int, bool, error) {
+	result, err := m.Score(doc)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return result.Scores, result.Best, result.Strict, nil
+}
+
+// Score computes the posterior of each category along with how confident the decision is
+func (m *compiled) Score(doc []string) (*ScoreResult, error) {
+	return score(m, doc)
+}
+
+// Begin starts a transaction that can never be committed, a compiled classifier is read only
+func (m *compiled) Begin() *Tx {
+	return &Tx{c: m, categories: m.categoryCount()}
+}
+
+// readOnly rejects learning for the classifiers that cannot learn
+type readOnly struct{}
+
+// Learn always fails, the classifier is read only
+func (readOnly) Learn(doc []string, category int) error {
+	return ErrReadOnly
+}
+
+// LearnBatch always fails, the classifier is read only
+func (readOnly) LearnBatch(docs []Document) error {
+	return ErrReadOnly
+}
+
+// LearnPositive always fails, the classifier is read only
+func (readOnly) LearnPositive(doc []string) error {
+	return ErrReadOnly
+}
+
+// LearnNegative always fails, the classifier is read only
+func (readOnly) LearnNegative(doc []string) error {
+	return ErrReadOnly
+}
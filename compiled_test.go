@@ -0,0 +1,157 @@
+package bayesian
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syntheticTraining makes documents of random words drawn from a Zipf distribution, so every category has a few very
+// common words and a long tail
+func syntheticTraining(categories int, vocabulary int, docs int) []Document {
+	rng := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(rng, 1.1, 1, uint64(vocabulary-1))
+	training := make([]Document, docs)
+	for i := range training {
+		category := i % categories
+		doc := make([]string, 50)
+		for j := range doc {
+			doc[j] = fmt.Sprintf("w%d", (int(zipf.Uint64())+category*7)%vocabulary)
+		}
+		training[i] = Document{Words: doc, Category: category}
+	}
+	return training
+}
+
+// syntheticCorpus learns the documents of syntheticTraining
+func syntheticCorpus(t testing.TB, categories int, vocabulary int, docs int, opts ...Option) Classifier {
+	t.Helper()
+	return trainedClassifier(t, categories, syntheticTraining(categories, vocabulary, docs), opts...)
+}
+
+func syntheticDocs(n int, vocabulary int) [][]string {
+	rng := rand.New(rand.NewSource(2))
+	docs := make([][]string, n)
+	for i := range docs {
+		docs[i] = make([]string, 30)
+		for j := range docs[i] {
+			// a few words are past the end of the vocabulary so unknown words are exercised too
+			docs[i][j] = fmt.Sprintf("w%d", rng.Intn(vocabulary+vocabulary/10))
+		}
+	}
+	return docs
+}
+
+func TestCompiledMatchesClassifier(t *testing.T) {
+	for _, policy := range []UnknownPolicy{UnknownSmooth, UnknownIgnore, UnknownHapax, UnknownShape} {
+		for _, s := range smoothers {
+			if _, ok := s.(Lidstone); ok {
+				s = Lidstone{Alphas: []float64{0.1, 0.5, 2}}
+			}
+
+			c := syntheticCorpus(t, 3, 500, 60, WithSmoother(s), WithUnknownPolicy(policy))
+			frozen, err := Compile(c)
+			assert.NoError(t, err)
+
+			for _, doc := range append(syntheticDocs(20, 500), []string{"x9", "?"}) {
+				want, err := c.Score(doc)
+				assert.NoError(t, err)
+				got, err := frozen.Score(doc)
+				assert.NoError(t, err)
+				assert.Equal(t, want, got, "%v %v", policy, s)
+			}
+		}
+	}
+}
+
+func TestCompiledIsReadOnly(t *testing.T) {
+	c := syntheticCorpus(t, 2, 100, 10)
+	frozen, err := Compile(c)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ErrReadOnly, frozen.Learn([]string{"a"}, 0))
+	assert.Equal(t, ErrReadOnly, frozen.LearnBatch(nil))
+	assert.Equal(t, ErrReadOnly, frozen.(BinaryClassifier).LearnPositive([]string{"a"}))
+	assert.Equal(t, ErrReadOnly, frozen.(BinaryClassifier).LearnNegative([]string{"a"}))
+
+	tx := frozen.Begin()
+	assert.NoError(t, tx.Learn([]string{"a"}, 1))
+	assert.Equal(t, ErrInvalidCategory, tx.Learn([]string{"a"}, 2))
+	assert.Equal(t, ErrReadOnly, tx.Commit())
+
+	// learning more in the original does not change the compiled copy
+	before, err := frozen.Score([]string{"w1"})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Learn([]string{"w1", "w1", "w1"}, 1))
+	after, err := frozen.Score([]string{"w1"})
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestCompileErrors(t *testing.T) {
+	untrained, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+	_, err = Compile(untrained)
+	assert.Equal(t, ErrUntrained, err)
+
+	frozen, err := Compile(syntheticCorpus(t, 2, 100, 10))
+	assert.NoError(t, err)
+	_, err = Compile(frozen)
+	assert.Equal(t, ErrNotCompilable, err)
+
+	// words past what the 32 bit offsets address fail the compile instead of wrapping around
+	defer func(max uint64) { maxTableBytes = max }(maxTableBytes)
+	maxTableBytes = 64
+	_, err = Compile(syntheticCorpus(t, 2, 100, 10))
+	assert.Equal(t, ErrModelTooLarge, err)
+}
+
+func TestCompiledEncodeDecode(t *testing.T) {
+	c := syntheticCorpus(t, 2, 200, 20)
+	frozen, err := Compile(c)
+	assert.NoError(t, err)
+
+	m := &Model{Labels: []string{"a", "b"}, Classifier: frozen}
+	buf := new(bytes.Buffer)
+	assert.NoError(t, m.Save(buf))
+	loaded, err := LoadModel(buf)
+	assert.NoError(t, err)
+
+	doc := syntheticDocs(1, 200)[0]
+	want, err := c.Score(doc)
+	assert.NoError(t, err)
+	got, err := loaded.Classifier.Score(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	buf.Reset()
+	assert.NoError(t, gob.NewEncoder(buf).Encode(&frozen))
+}
+
+func benchmarkScores(b *testing.B, compile bool) {
+	c := syntheticCorpus(b, 4, 50000, 2000)
+	if compile {
+		var err error
+		c, err = Compile(c)
+		assert.NoError(b, err)
+	}
+
+	docs := syntheticDocs(100, 50000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _, _ = c.Scores(docs[i%len(docs)])
+	}
+}
+
+func BenchmarkScoresMutable(b *testing.B) {
+	benchmarkScores(b, false)
+}
+
+func BenchmarkScoresCompiled(b *testing.B) {
+	benchmarkScores(b, true)
+}
@@ -0,0 +1,148 @@
+package bayesian
+
+import (
+	"errors"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Document is a single tokenized document along with the category it was filed under
+type Document struct {
+	Name     string
+	Category int
+	Words    []string
+}
+
+// Corpus is a labeled collection of documents, split into a training and a test set
+type Corpus struct {
+	Categories []string
+	Train      []Document
+	Test       []Document
+}
+
+// CorpusOptions controls how a corpus is read from a directory tree
+type CorpusOptions struct {
+	// Tokenizer splits the contents of a file into words, Tokenize is used when it is nil
+	Tokenizer func(string) []string
+	// TestFraction is the fraction of files held out for the test set, chosen by a hash of the file name
+	TestFraction float64
+}
+
+// ErrEmptyCorpus is an error we throw when a directory tree holds no category folders
+var ErrEmptyCorpus = errors.New("bayesian: corpus has no categories")
+
+// ErrInvalidTestFraction is an error we throw when the test fraction is outside of [0, 1]
+var ErrInvalidTestFraction = errors.New("bayesian: invalid test fraction")
+
+// LoadCorpus reads a directory-per-category corpus rooted at dir, every subdirectory of dir is a category named after
+// the folder and every regular file below it (at any depth, so Maildir's cur/new folders work) is one document
+func LoadCorpus(fsys fs.FS, dir string, opts CorpusOptions) (*Corpus, error) {
+	if opts.TestFraction < 0 || opts.TestFraction > 1 {
+		return nil, ErrInvalidTestFraction
+	}
+
+	tokenize := opts.Tokenizer
+	if tokenize == nil {
+		tokenize = Tokenize
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	corpus := &Corpus{}
+	for _, entry := range entries {
+		if entry.IsDir() && !isHidden(entry.Name()) {
+			corpus.Categories = append(corpus.Categories, entry.Name())
+		}
+	}
+
+	if len(corpus.Categories) == 0 {
+		return nil, ErrEmptyCorpus
+	}
+	sort.Strings(corpus.Categories)
+
+	for category, name := range corpus.Categories {
+		err := fs.WalkDir(fsys, path.Join(dir, name), func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if isHidden(d.Name()) && p != path.Join(dir, name) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			contents, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+
+			rel := strings.TrimPrefix(p, path.Clean(dir)+"/")
+			doc := Document{Name: rel, Category: category, Words: tokenize(string(contents))}
+			if heldOut(rel, opts.TestFraction) {
+				corpus.Test = append(corpus.Test, doc)
+			} else {
+				corpus.Train = append(corpus.Train, doc)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return corpus, nil
+}
+
+// Learn teaches the classifier every document in the training set
+func (c *Corpus) Learn(classifier Classifier) error {
+	for _, doc := range c.Train {
+		if err := classifier.Learn(doc.Words, doc.Category); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewClassifier creates a classifier with one category per folder and trains it on the training set
+func (c *Corpus) NewClassifier(smoothingFactor float64, opts ...Option) (Classifier, error) {
+	classifier, err := NewClassifier(len(c.Categories), smoothingFactor, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Learn(classifier); err != nil {
+		return nil, err
+	}
+
+	return classifier, nil
+}
+
+// heldOut deterministically decides whether a file belongs in the test set from a hash of its name
+func heldOut(name string, fraction float64) bool {
+	if fraction <= 0 {
+		return false
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return float64(h.Sum32())/(1<<32) < fraction
+}
+
+// isHidden reports whether a file name is a dot file, such as the .DS_Store or .mh_sequences files left by other tools
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
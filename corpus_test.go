@@ -0,0 +1,80 @@
+package bayesian
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCorpusFS() fstest.MapFS {
+	return fstest.MapFS{
+		"news/sci.space/101":         {Data: []byte("The rocket reached orbit")},
+		"news/sci.space/102":         {Data: []byte("Orbit insertion burn for the rocket")},
+		"news/sci.space/103":         {Data: []byte("Launch window and orbit")},
+		"news/rec.autos/201":         {Data: []byte("The engine and the brakes")},
+		"news/rec.autos/202":         {Data: []byte("Brakes squeal, engine stalls")},
+		"news/rec.autos/cur/203":     {Data: []byte("Engine oil change")},
+		"news/rec.autos/.DS_Store":   {Data: []byte("junk")},
+		"news/.hidden/301":           {Data: []byte("ignored")},
+		"news/README":                {Data: []byte("not a category")},
+		"news/rec.autos/tmp/.lock":   {Data: []byte("")},
+		"news/sci.space/.cache/skip": {Data: []byte("ignored")},
+	}
+}
+
+func TestLoadCorpus(t *testing.T) {
+	corpus, err := LoadCorpus(testCorpusFS(), "news", CorpusOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rec.autos", "sci.space"}, corpus.Categories)
+	assert.Len(t, corpus.Train, 6)
+	assert.Empty(t, corpus.Test)
+
+	for _, doc := range corpus.Train {
+		if doc.Name == "rec.autos/cur/203" {
+			assert.Equal(t, 0, doc.Category)
+			assert.Equal(t, []string{"engine", "oil", "change"}, doc.Words)
+		}
+	}
+
+	c, err := corpus.NewClassifier(1)
+	assert.NoError(t, err)
+	_, idx, _, err := c.Scores([]string{"rocket", "orbit"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, idx)
+	_, idx, _, err = c.Scores([]string{"brakes"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, idx)
+}
+
+func TestLoadCorpusSplit(t *testing.T) {
+	first, err := LoadCorpus(testCorpusFS(), "news", CorpusOptions{TestFraction: 0.5})
+	assert.NoError(t, err)
+	second, err := LoadCorpus(testCorpusFS(), "news", CorpusOptions{TestFraction: 0.5})
+	assert.NoError(t, err)
+
+	// the split only depends on the file names, so it is the same every time
+	assert.Equal(t, first.Test, second.Test)
+	assert.Equal(t, 6, len(first.Train)+len(first.Test))
+
+	all, err := LoadCorpus(testCorpusFS(), "news", CorpusOptions{TestFraction: 1})
+	assert.NoError(t, err)
+	assert.Empty(t, all.Train)
+	assert.Len(t, all.Test, 6)
+}
+
+func TestLoadCorpusErrors(t *testing.T) {
+	_, err := LoadCorpus(testCorpusFS(), "news", CorpusOptions{TestFraction: 2})
+	assert.Equal(t, ErrInvalidTestFraction, err)
+
+	_, err = LoadCorpus(fstest.MapFS{"empty/file": {}}, "empty", CorpusOptions{})
+	assert.Equal(t, ErrEmptyCorpus, err)
+
+	_, err = LoadCorpus(testCorpusFS(), "missing", CorpusOptions{})
+	assert.Error(t, err)
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"hello", "wörld", "42"}, Tokenize("Hello, Wörld! -- 42"))
+	assert.Empty(t, Tokenize(" ... "))
+}
@@ -0,0 +1,65 @@
+package bayesian
+
+import (
+	"errors"
+	"time"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// ErrNotDecayed is an error we throw when decaying a classifier that was not created with WithDecay
+var ErrNotDecayed = errors.New("bayesian: classifier does not decay")
+
+// WithDecay makes learned counts fade exponentially, halving every halfLife, so that recent documents outweigh old
+// ones. The category totals and with them the priors fade the same way. Time only moves when Decay is called, the
+// clock starts at the time the classifier is created. The half-life and the clock are saved with the model.
+//
+// Words whose counts have faded away are forgotten, so the vocabulary of a stream stays bounded. Decayed counts are
+// not whole numbers, so a decayed classifier cannot be compiled. The hapax and shape unknown policies count the words
+// as of the clock, rounded to whole counts.
+func WithDecay(halfLife time.Duration) Option {
+	return func(c *classifier) error {
+		tree, err := radix.NewDecayed(c.Tree.CategoryCount(), halfLife, time.Now())
+		if err != nil {
+			return err
+		}
+
+		c.Tree = tree
+		return nil
+	}
+}
+
+// WithAutoDecay is WithDecay with a clock that follows the current time, documents are learned and scored as of the
+// moment they arrive
+func WithAutoDecay(halfLife time.Duration) Option {
+	return func(c *classifier) error {
+		if err := WithDecay(halfLife)(c); err != nil {
+			return err
+		}
+
+		c.AutoDecay = true
+		return nil
+	}
+}
+
+// Decay moves the clock of a classifier created with WithDecay forward to now, everything learned before fades by
+// the time that passed. The clock never moves back.
+func Decay(c Classifier, now time.Time) error {
+	cl, ok := c.(*classifier)
+	if !ok {
+		return ErrNotDecayed
+	}
+
+	decayer, ok := cl.Tree.(radix.Decayer)
+	if !ok {
+		return ErrNotDecayed
+	}
+
+	lock := cl.lock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	decayer.Decay(now)
+	cl.resetUnknownCounts()
+	return nil
+}
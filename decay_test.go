@@ -0,0 +1,120 @@
+package bayesian
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDecay(t *testing.T) {
+	_, err := NewClassifier(2, 1, WithDecay(0))
+	assert.Equal(t, radix.ErrInvalidHalfLife, err)
+
+	plain, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrNotDecayed, Decay(plain, time.Now()))
+
+	c, err := NewClassifier(2, 1, WithDecay(24*time.Hour))
+	assert.NoError(t, err)
+	start := c.(*classifier).Tree.(radix.Decayer).Clock()
+
+	// "prize" used to be spam, but over time it became a ham word
+	for i := 0; i < 8; i++ {
+		assert.NoError(t, c.Learn([]string{"prize", "money"}, Positive))
+	}
+	assert.NoError(t, c.Learn([]string{"meeting", "notes"}, Negative))
+
+	before, err := c.Score([]string{"prize"})
+	assert.NoError(t, err)
+	assert.Equal(t, Positive, before.Best)
+
+	assert.NoError(t, Decay(c, start.Add(7*24*time.Hour)))
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, c.Learn([]string{"prize", "meeting"}, Negative))
+	}
+
+	after, err := c.Score([]string{"prize"})
+	assert.NoError(t, err)
+	assert.Equal(t, Negative, after.Best)
+
+	// the half-life and the clock are saved with the model
+	m := &Model{Labels: []string{"ham", "spam"}, Classifier: c}
+	buf := new(bytes.Buffer)
+	assert.NoError(t, m.Save(buf))
+	loaded, err := LoadModel(buf)
+	assert.NoError(t, err)
+
+	decayer := loaded.Classifier.(*classifier).Tree.(radix.Decayer)
+	assert.Equal(t, 24*time.Hour, decayer.HalfLife())
+	assert.True(t, start.Add(7*24*time.Hour).Equal(decayer.Clock()))
+	reloaded, err := loaded.Classifier.Score([]string{"prize"})
+	assert.NoError(t, err)
+	assert.Equal(t, after, reloaded)
+
+	_, err = Compile(c)
+	assert.Equal(t, ErrNotCompilable, err)
+}
+
+func TestWithAutoDecay(t *testing.T) {
+	c, err := NewClassifier(2, 1, WithAutoDecay(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, c.(*classifier).AutoDecay)
+
+	decayer := c.(*classifier).Tree.(radix.Decayer)
+	created := decayer.Clock()
+	time.Sleep(time.Millisecond)
+
+	// learning moves the clock to the current time
+	assert.NoError(t, c.Learn([]string{"word"}, Positive))
+	assert.True(t, decayer.Clock().After(created))
+
+	result, err := c.Score([]string{"word"})
+	assert.NoError(t, err)
+	assert.Equal(t, Positive, result.Best)
+}
+
+func TestDecayedVocabulary(t *testing.T) {
+	c, err := NewClassifier(2, 1, WithDecay(time.Hour), WithUnknownPolicy(UnknownHapax), WithFuzzyMatching(1, 0.5))
+	assert.NoError(t, err)
+	cl := c.(*classifier)
+	start := cl.Tree.(radix.Decayer).Clock()
+
+	assert.NoError(t, c.Learn([]string{"invoice", "invoice", "rare"}, Positive))
+	assert.NoError(t, c.Learn([]string{"lunch"}, Negative))
+	assert.Equal(t, []WordCount{{Word: "invoice", Counts: []int{0, 2}}, {Word: "rare", Counts: []int{0, 1}}},
+		cl.TopWords(Positive, 5))
+
+	// unseen words borrow the counts of near ones and of the hapax legomena
+	typo, err := c.Score([]string{"invoise"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, typo.Unknown)
+	assert.Equal(t, Positive, typo.Best)
+	s := cl.smoothingContext()
+	u := cl.unknownCountsFor(s)
+	assert.Equal(t, []int{1, 1}, u.hapax)
+
+	// the pseudo counts fade with the words when they are read ahead of the clock they were built at, and are built
+	// again once they are too old
+	assert.Equal(t, 1.0, unknownFade(u, s))
+	s.at = s.at.Add(time.Minute)
+	assert.True(t, cl.unknownCountsFor(s) == u)
+	s.at = s.at.Add(59 * time.Minute)
+	assert.InDelta(t, 0.5, unknownFade(u, s), 1e-9)
+	assert.False(t, cl.unknownCountsFor(s) == u)
+
+	// a failed batch is rolled back
+	failing := &failingTree{removableTree: cl.Tree.(removableTree), budget: 1}
+	cl.Tree = failing
+	err = c.LearnBatch([]Document{{Words: []string{"spill", "over"}, Category: Negative}})
+	assert.Equal(t, radix.ErrCannotCreateNode, err)
+	cl.Tree = failing.removableTree
+	assert.Equal(t, 3, cl.Tree.UniqueWords())
+
+	// words that faded away are forgotten
+	assert.NoError(t, Decay(c, start.Add(12*time.Hour)))
+	assert.Equal(t, 0, cl.Tree.UniqueWords())
+	assert.Empty(t, cl.TopWords(Positive, 5))
+}
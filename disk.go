@@ -0,0 +1,112 @@
+package bayesian
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"reflect"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// DiskClassifier is a classifier whose counts are stored in a file rather than in memory, for vocabularies that do
+// not fit in memory. What it learns only becomes durable when it is committed.
+type DiskClassifier struct {
+	Classifier
+	c    *classifier
+	tree *radix.DiskTree
+}
+
+// ErrSettingsMismatch is an error we throw when a disk classifier is opened with other settings than it was created with
+var ErrSettingsMismatch = errors.New("bayesian: settings do not match the stored classifier")
+
+// diskSettings are the smoothing settings kept in the file of a disk classifier
+type diskSettings struct {
+	SmoothingFactor float64
+	Smoother        Smoother
+	Unknown         UnknownPolicy
+}
+
+// OpenDiskClassifier opens the classifier stored at path, creating it when the file does not exist. At most
+// cachePages pages of the file are kept in memory. The smoothing settings are stored in the file when it is created,
+// and an existing file must be opened with the same smoothingFactor, smoother and unknown token policy or
+// ErrSettingsMismatch is returned. The counts are always kept in the file, so options choosing another tree, such as
+// WithArenaTree, WithDecay or WithFeatureHashing, return ErrUnsupportedOption.
+func OpenDiskClassifier(path string, categories int, smoothingFactor float64, cachePages int, opts ...Option) (*DiskClassifier, error) {
+	c, err := newClassifier(categories, smoothingFactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	memory := c.Tree
+	if err := c.apply(opts); err != nil {
+		return nil, err
+	}
+	if c.Tree != memory || c.AutoDecay {
+		return nil, ErrUnsupportedOption
+	}
+
+	// the disk tree cannot find words by edit distance
+	if c.FuzzyEdits > 0 {
+		return nil, ErrInvalidFuzzyMatching
+	}
+
+	tree, err := radix.Open(path, categories, cachePages)
+	if err != nil {
+		return nil, err
+	}
+	c.Tree = tree
+
+	if err := loadDiskSettings(c, tree); err != nil {
+		_ = tree.Close()
+		return nil, err
+	}
+
+	return &DiskClassifier{Classifier: c, c: c, tree: tree}, nil
+}
+
+// loadDiskSettings checks the settings of c against the ones stored in the tree, or stores them when there are none
+func loadDiskSettings(c *classifier, tree *radix.DiskTree) error {
+	settings := diskSettings{
+		SmoothingFactor: c.SmoothingFactor,
+		Smoother:        c.Smoother,
+		Unknown:         c.Unknown,
+	}
+
+	if data := tree.Metadata(); data != nil {
+		var stored diskSettings
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&stored); err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(settings, stored) {
+			return ErrSettingsMismatch
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&settings); err != nil {
+		return err
+	}
+	if err := tree.SetMetadata(buf.Bytes()); err != nil {
+		return err
+	}
+	return tree.Commit()
+}
+
+// Commit makes everything learned so far durable, a batch is never split between two commits
+func (d *DiskClassifier) Commit() error {
+	lock := d.c.lock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	return d.tree.Commit()
+}
+
+// Close closes the file, anything learned since the last commit is lost
+func (d *DiskClassifier) Close() error {
+	lock := d.c.lock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	return d.tree.Close()
+}
@@ -0,0 +1,111 @@
+package bayesian
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskClassifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.db")
+	d, err := OpenDiskClassifier(path, 3, 1, 16, WithUnknownPolicy(UnknownHapax))
+	assert.NoError(t, err)
+
+	memory := syntheticCorpus(t, 3, 2000, 60, WithUnknownPolicy(UnknownHapax))
+	for _, doc := range syntheticTraining(3, 2000, 60) {
+		assert.NoError(t, d.Learn(doc.Words, doc.Category))
+	}
+	assert.NoError(t, d.Commit())
+	assert.NoError(t, d.Learn([]string{"uncommitted"}, 0))
+	assert.NoError(t, d.Close())
+
+	d, err = OpenDiskClassifier(path, 3, 1, 16, WithUnknownPolicy(UnknownHapax))
+	assert.NoError(t, err)
+	defer func() { _ = d.Close() }()
+
+	for _, doc := range append(syntheticDocs(20, 2000), []string{"uncommitted"}) {
+		want, err := memory.Score(doc)
+		assert.NoError(t, err)
+		got, err := d.Score(doc)
+		assert.NoError(t, err)
+		assert.InDeltaSlice(t, want.Posteriors, got.Posteriors, 1e-12)
+		assert.Equal(t, want.Known, got.Known)
+	}
+
+	// a disk classifier can be compiled like any other
+	_, err = Compile(d.c)
+	assert.NoError(t, err)
+
+	_, err = OpenDiskClassifier(path, 2, 1, 16)
+	assert.Error(t, err)
+}
+
+func TestDiskClassifierSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.db")
+	d, err := OpenDiskClassifier(path, 2, 0.5, 16, WithSmoother(Dirichlet{Mu: 100}), WithUnknownPolicy(UnknownHapax))
+	assert.NoError(t, err)
+	assert.NoError(t, d.Learn([]string{"tall", "tall", "rare"}, 0))
+	assert.NoError(t, d.Learn([]string{"short"}, 1))
+
+	want, err := d.Score([]string{"tall", "unseen"})
+	assert.NoError(t, err)
+	assert.NoError(t, d.Commit())
+	assert.NoError(t, d.Close())
+
+	// the file is opened again with the settings it was created with
+	for _, opts := range [][]Option{
+		{WithSmoother(Dirichlet{Mu: 100})},
+		{WithSmoother(Dirichlet{Mu: 50}), WithUnknownPolicy(UnknownHapax)},
+		{WithUnknownPolicy(UnknownHapax)},
+	} {
+		_, err = OpenDiskClassifier(path, 2, 0.5, 16, opts...)
+		assert.Equal(t, ErrSettingsMismatch, err)
+	}
+	_, err = OpenDiskClassifier(path, 2, 1, 16, WithSmoother(Dirichlet{Mu: 100}), WithUnknownPolicy(UnknownHapax))
+	assert.Equal(t, ErrSettingsMismatch, err)
+
+	d, err = OpenDiskClassifier(path, 2, 0.5, 16, WithSmoother(Dirichlet{Mu: 100}), WithUnknownPolicy(UnknownHapax))
+	assert.NoError(t, err)
+	defer func() { _ = d.Close() }()
+
+	got, err := d.Score([]string{"tall", "unseen"})
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, want.Posteriors, got.Posteriors, 1e-12)
+}
+
+func TestDiskClassifierTreeOptions(t *testing.T) {
+	for _, opt := range []Option{
+		WithPersistentTree(),
+		WithArenaTree(),
+		WithDecay(time.Hour),
+		WithAutoDecay(time.Hour),
+		WithWindow(3, time.Hour),
+		WithFeatureHashing(10, false),
+		WithCountMinSketch(0.01, 0.01),
+	} {
+		path := filepath.Join(t.TempDir(), "model.db")
+		_, err := OpenDiskClassifier(path, 2, 1, 16, opt)
+		assert.Equal(t, ErrUnsupportedOption, err)
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestDiskClassifierLongWords(t *testing.T) {
+	d, err := OpenDiskClassifier(filepath.Join(t.TempDir(), "model.db"), 2, 1, 16)
+	assert.NoError(t, err)
+	defer func() { _ = d.Close() }()
+
+	long := strings.Repeat("x", 10000)
+	assert.NoError(t, d.Learn([]string{long, "word"}, 0))
+	assert.NoError(t, d.Learn([]string{"word"}, 1))
+
+	score, err := d.Score([]string{long})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, score.Known)
+	assert.Equal(t, 0, score.Best)
+}
@@ -0,0 +1,101 @@
+package bayesian
+
+// Evaluation holds the result of classifying a labeled test set
+type Evaluation struct {
+	// Confusion counts documents by their actual category (row) and predicted category (column)
+	Confusion [][]int
+	Correct   int
+	Total     int
+}
+
+// Evaluate classifies every document and tallies the predictions against the labels they were filed under
+func Evaluate(c Classifier, categories int, docs []Document) (*Evaluation, error) {
+	e := &Evaluation{Confusion: make([][]int, categories)}
+	for i := range e.Confusion {
+		e.Confusion[i] = make([]int, categories)
+	}
+
+	for _, doc := range docs {
+		_, predicted, _, err := c.Scores(doc.Words)
+		if err != nil {
+			return nil, err
+		}
+		if doc.Category >= 0 && doc.Category < categories {
+			e.Confusion[doc.Category][predicted]++
+		}
+
+		if predicted == doc.Category {
+			e.Correct++
+		}
+		e.Total++
+	}
+
+	return e, nil
+}
+
+// Accuracy is the fraction of documents whose predicted category matched their label
+func (e *Evaluation) Accuracy() float64 {
+	if e.Total == 0 {
+		return 0
+	}
+	return float64(e.Correct) / float64(e.Total)
+}
+
+// MultiLabelDocument is a tokenized document along with every label that applies to it
+type MultiLabelDocument struct {
+	Words  []string
+	Labels []int
+}
+
+// MultiLabelEvaluation holds the result of predicting the labels of a test set
+type MultiLabelEvaluation struct {
+	// HammingLoss is the fraction of (document, label) pairs that were predicted wrongly
+	HammingLoss float64
+	// SubsetAccuracy is the fraction of documents whose predicted labels exactly matched their labels
+	SubsetAccuracy float64
+	Total          int
+}
+
+// EvaluateMultiLabel predicts the labels of every document and compares them with the labels it has
+func EvaluateMultiLabel(m MultiLabelClassifier, docs []MultiLabelDocument) (*MultiLabelEvaluation, error) {
+	e := &MultiLabelEvaluation{Total: len(docs)}
+	if len(docs) == 0 {
+		return e, nil
+	}
+
+	wrong, exact := 0, 0
+	for _, doc := range docs {
+		predicted, err := m.Predict(doc.Words)
+		if err != nil {
+			return nil, err
+		}
+
+		actual := make([]bool, m.Labels())
+		for _, label := range doc.Labels {
+			if label >= 0 && label < len(actual) {
+				actual[label] = true
+			}
+		}
+
+		got := make([]bool, m.Labels())
+		for _, label := range predicted {
+			got[label] = true
+		}
+
+		mistakes := 0
+		for i := range actual {
+			if actual[i] != got[i] {
+				mistakes++
+			}
+		}
+
+		wrong += mistakes
+		if mistakes == 0 {
+			exact++
+		}
+	}
+
+	e.HammingLoss = float64(wrong) / float64(len(docs)*m.Labels())
+	e.SubsetAccuracy = float64(exact) / float64(len(docs))
+	return e, nil
+}
@@ -0,0 +1,32 @@
+package bayesian
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	m := testModel(t)
+	docs := []Document{
+		{Words: []string{"lunch"}, Category: 0},
+		{Words: []string{"lottery"}, Category: 1},
+		{Words: []string{"winner", "report"}, Category: 0},
+	}
+
+	e, err := Evaluate(m.Classifier, len(m.Labels), docs)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, e.Total)
+	assert.Equal(t, 2, e.Correct)
+	assert.InDelta(t, 2.0/3.0, e.Accuracy(), 1e-9)
+	assert.Equal(t, [][]int{{1, 1}, {0, 1}}, e.Confusion)
+
+	e, err = Evaluate(m.Classifier, 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, e.Accuracy())
+
+	untrained, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+	_, err = Evaluate(untrained, 2, docs)
+	assert.Equal(t, ErrUntrained, err)
+}
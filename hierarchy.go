@@ -0,0 +1,270 @@
+package bayesian
+
+import (
+	"encoding/gob"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PathSeparator separates the levels of a path in a taxonomy, as in "billing/refund/partial"
+const PathSeparator = "/"
+
+// HierarchicalClassifier classifies documents into a tree shaped taxonomy, it keeps one classifier per interior node
+// of the taxonomy that chooses between that node's children
+type HierarchicalClassifier interface {
+	// Learn learns a document as a member of a path, which teaches the classifier of every ancestor of the path
+	Learn(doc []string, path string) error
+	// Classify routes a document from the root towards the leaves, keeping the beam most likely paths at every
+	// level, and returns the complete paths found from most to least likely
+	Classify(doc []string, beam int) ([]PathResult, error)
+	// Paths returns every path in the taxonomy in sorted order
+	Paths() []string
+}
+
+// PathResult is a path through the taxonomy along with how confident each step along it was
+type PathResult struct {
+	Path string
+	// Probability is the product of the confidences of every level
+	Probability float64
+	Levels      []LevelConfidence
+}
+
+// LevelConfidence is the choice made at one level of the taxonomy
+type LevelConfidence struct {
+	// Path is the path down to and including this level
+	Path       string
+	Confidence float64
+}
+
+// ErrUnknownPath is an error we throw when learning a path that is not in the taxonomy
+var ErrUnknownPath = errors.New("bayesian: unknown path")
+
+// ErrEmptyTaxonomy is an error we throw when a hierarchical classifier is created without any paths
+var ErrEmptyTaxonomy = errors.New("bayesian: taxonomy has no paths")
+
+// ErrInvalidBeam is an error we throw when the beam width is not positive
+var ErrInvalidBeam = errors.New("bayesian: invalid beam width")
+
+type hierarchy struct {
+	Root *taxonomyNode
+
+	mu sync.RWMutex
+}
+
+// taxonomyNode is a node of the taxonomy, interior nodes have a classifier choosing between their children
+type taxonomyNode struct {
+	Path       string
+	Children   []*taxonomyNode
+	Classifier Classifier
+}
+
+func init() {
+	gob.Register(&hierarchy{})
+}
+
+// NewHierarchicalClassifier creates a classifier for the taxonomy made up of the given paths and all their ancestors,
+// the smoothing factor and options apply to the classifier of every interior node
+func NewHierarchicalClassifier(paths []string, smoothingFactor float64, opts ...Option) (HierarchicalClassifier, error) {
+	if len(paths) == 0 {
+		return nil, ErrEmptyTaxonomy
+	}
+
+	root := &taxonomyNode{}
+	for _, path := range paths {
+		if err := root.add(splitPath(path)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := root.buildClassifiers(smoothingFactor, opts); err != nil {
+		return nil, err
+	}
+	return &hierarchy{Root: root}, nil
+}
+
+// splitPath breaks a path into its levels, ignoring leading, trailing and repeated separators
+func splitPath(path string) []string {
+	var levels []string
+	for _, level := range strings.Split(path, PathSeparator) {
+		if level != "" {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// add inserts the nodes of a path below this node, keeping children sorted
+func (n *taxonomyNode) add(levels []string) error {
+	if len(levels) == 0 {
+		if n.Path == "" {
+			return ErrUnknownPath
+		}
+		return nil
+	}
+
+	path := levels[0]
+	if n.Path != "" {
+		path = n.Path + PathSeparator + levels[0]
+	}
+
+	idx := sort.Search(len(n.Children), func(i int) bool { return n.Children[i].Path >= path })
+	if idx == len(n.Children) || n.Children[idx].Path != path {
+		n.Children = append(n.Children, nil)
+		copy(n.Children[idx+1:], n.Children[idx:])
+		n.Children[idx] = &taxonomyNode{Path: path}
+	}
+	return n.Children[idx].add(levels[1:])
+}
+
+func (n *taxonomyNode) buildClassifiers(smoothingFactor float64, opts []Option) error {
+	if len(n.Children) == 0 {
+		return nil
+	}
+
+	c, err := NewClassifier(len(n.Children), smoothingFactor, opts...)
+	if err != nil {
+		return err
+	}
+	n.Classifier = c
+
+	for _, child := range n.Children {
+		if err := child.buildClassifiers(smoothingFactor, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *hierarchy) Paths() []string {
+	var paths []string
+	var visit func(n *taxonomyNode)
+	visit = func(n *taxonomyNode) {
+		if n.Path != "" {
+			paths = append(paths, n.Path)
+		}
+		for _, child := range n.Children {
+			visit(child)
+		}
+	}
+
+	visit(h.Root)
+	return paths
+}
+
+func (h *hierarchy) Learn(doc []string, path string) error {
+	levels := splitPath(path)
+	if len(levels) == 0 {
+		return ErrUnknownPath
+	}
+
+	// find the child chosen at every level before learning anything, so an unknown path changes nothing
+	type step struct {
+		node  *taxonomyNode
+		child int
+	}
+
+	var steps []step
+	current := h.Root
+	for _, level := range levels {
+		want := level
+		if current.Path != "" {
+			want = current.Path + PathSeparator + level
+		}
+
+		idx := sort.Search(len(current.Children), func(i int) bool { return current.Children[i].Path >= want })
+		if idx == len(current.Children) || current.Children[idx].Path != want {
+			return ErrUnknownPath
+		}
+
+		steps = append(steps, step{node: current, child: idx})
+		current = current.Children[idx]
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, s := range steps {
+		if err := s.node.Classifier.Learn(doc, s.child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partialPath is a path being extended by the beam search
+type partialPath struct {
+	node    *taxonomyNode
+	logProb float64
+	levels  []LevelConfidence
+}
+
+func (h *hierarchy) Classify(doc []string, beam int) ([]PathResult, error) {
+	if beam <= 0 {
+		return nil, ErrInvalidBeam
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	frontier := []partialPath{{node: h.Root}}
+	for {
+		var next []partialPath
+		expanded := false
+		for _, p := range frontier {
+			if len(p.node.Children) == 0 {
+				next = append(next, p)
+				continue
+			}
+
+			expanded = true
+			posteriors, err := childPosteriors(p.node, doc)
+			if err != nil {
+				return nil, err
+			}
+
+			for i, child := range p.node.Children {
+				levels := make([]LevelConfidence, len(p.levels), len(p.levels)+1)
+				copy(levels, p.levels)
+				levels = append(levels, LevelConfidence{Path: child.Path, Confidence: posteriors[i]})
+				next = append(next, partialPath{node: child, logProb: p.logProb + math.Log(posteriors[i]), levels: levels})
+			}
+		}
+
+		sort.SliceStable(next, func(i, j int) bool { return next[i].logProb > next[j].logProb })
+		if len(next) > beam {
+			next = next[:beam]
+		}
+		frontier = next
+
+		if !expanded {
+			break
+		}
+	}
+
+	results := make([]PathResult, len(frontier))
+	for i, p := range frontier {
+		results[i] = PathResult{Path: p.node.Path, Probability: math.Exp(p.logProb), Levels: p.levels}
+	}
+	return results, nil
+}
+
+// childPosteriors scores a document at an interior node, a node that has not learned anything yet treats all of its
+// children as equally likely
+func childPosteriors(n *taxonomyNode, doc []string) ([]float64, error) {
+	result, err := n.Classifier.Score(doc)
+	if err == ErrUntrained {
+		uniform := make([]float64, len(n.Children))
+		for i := range uniform {
+			uniform[i] = 1 / float64(len(n.Children))
+		}
+		return uniform, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return result.Posteriors, nil
+}
@@ -0,0 +1,134 @@
+package bayesian
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var supportPaths = []string{
+	"billing/refund/partial",
+	"billing/refund/full",
+	"billing/invoice",
+	"account/login",
+	"account/delete",
+}
+
+// supportDocs are the documents of supportTaxonomy, the category of a document is the index of its path
+var supportDocs = []Document{
+	{Words: []string{"refund", "part", "of", "order", "money"}, Category: 0},
+	{Words: []string{"refund", "whole", "order", "money", "back"}, Category: 1},
+	{Words: []string{"invoice", "copy", "money", "vat"}, Category: 2},
+	{Words: []string{"password", "login", "locked"}, Category: 3},
+	{Words: []string{"delete", "account", "close"}, Category: 4},
+}
+
+func supportTaxonomy(t *testing.T) HierarchicalClassifier {
+	t.Helper()
+	h, err := NewHierarchicalClassifier(supportPaths, 1)
+	assert.NoError(t, err)
+
+	learnDocs(t, supportDocs, func(words []string, category int) error {
+		return h.Learn(words, supportPaths[category])
+	})
+	return h
+}
+
+func TestHierarchicalClassify(t *testing.T) {
+	h := supportTaxonomy(t)
+
+	results, err := h.Classify([]string{"refund", "whole", "order"}, 3)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "billing/refund/full", results[0].Path)
+	assert.Equal(t, []string{"billing", "billing/refund", "billing/refund/full"}, pathsOf(results[0].Levels))
+
+	product := 1.0
+	for _, level := range results[0].Levels {
+		assert.True(t, level.Confidence > 0 && level.Confidence <= 1)
+		product *= level.Confidence
+	}
+	assert.InDelta(t, product, results[0].Probability, 1e-12)
+
+	for i := 1; i < len(results); i++ {
+		assert.True(t, results[i-1].Probability >= results[i].Probability)
+	}
+
+	results, err = h.Classify([]string{"password", "locked"}, 1)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "account/login", results[0].Path)
+
+	// the probabilities of all the leaves add up to one when the beam is wide enough to keep every path
+	results, err = h.Classify([]string{"money"}, 10)
+	assert.NoError(t, err)
+	assert.Len(t, results, 5)
+	total := 0.0
+	for _, r := range results {
+		total += r.Probability
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+
+	_, err = h.Classify([]string{"money"}, 0)
+	assert.Equal(t, ErrInvalidBeam, err)
+}
+
+func TestHierarchicalLearn(t *testing.T) {
+	h := supportTaxonomy(t)
+	assert.Equal(t, ErrUnknownPath, h.Learn([]string{"x"}, "billing/chargeback"))
+	assert.Equal(t, ErrUnknownPath, h.Learn([]string{"x"}, ""))
+
+	// learning a leaf teaches every ancestor
+	root := h.(*hierarchy).Root
+	assert.Equal(t, []int{6, 14}, root.Classifier.(Inspector).Stats().CategoryTotals)
+	refund := root.Children[1].Children[1]
+	assert.Equal(t, "billing/refund", refund.Path)
+	assert.Equal(t, []int{5, 5}, refund.Classifier.(Inspector).Stats().CategoryTotals)
+
+	assert.Equal(t, []string{"account", "account/delete", "account/login", "billing", "billing/invoice",
+		"billing/refund", "billing/refund/full", "billing/refund/partial"}, h.Paths())
+
+	_, err := NewHierarchicalClassifier(nil, 1)
+	assert.Equal(t, ErrEmptyTaxonomy, err)
+	_, err = NewHierarchicalClassifier([]string{"/"}, 1)
+	assert.Equal(t, ErrUnknownPath, err)
+}
+
+func TestHierarchicalUntrainedBranch(t *testing.T) {
+	h, err := NewHierarchicalClassifier([]string{"a/x", "a/y", "b"}, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, h.Learn([]string{"apple", "apple", "apple"}, "a"))
+	assert.NoError(t, h.Learn([]string{"banana"}, "b"))
+
+	// nothing was learned below "a", so its children split its probability evenly
+	results, err := h.Classify([]string{"apple"}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "a/x", results[0].Path)
+	assert.Equal(t, 0.5, results[0].Levels[1].Confidence)
+	assert.Equal(t, results[0].Probability, results[1].Probability)
+}
+
+func TestHierarchicalEncodeDecode(t *testing.T) {
+	h := supportTaxonomy(t)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, gob.NewEncoder(buf).Encode(&h))
+	var decoded HierarchicalClassifier
+	assert.NoError(t, gob.NewDecoder(buf).Decode(&decoded))
+
+	want, err := h.Classify([]string{"invoice", "vat"}, 2)
+	assert.NoError(t, err)
+	got, err := decoded.Classify([]string{"invoice", "vat"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func pathsOf(levels []LevelConfidence) []string {
+	var paths []string
+	for _, level := range levels {
+		paths = append(paths, level.Path)
+	}
+	return paths
+}
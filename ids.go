@@ -0,0 +1,142 @@
+package bayesian
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrShortBuffer is an error we throw when a buffer has fewer slots than the classifier has categories
+var ErrShortBuffer = errors.New("bayesian: buffer is shorter than the number of categories")
+
+// UnknownID stands for a word the classifier never learned, it is scored like any unseen word
+const UnknownID = ^uint32(0)
+
+// IDScorer is implemented by classifiers that give each word an integer ID, so that documents can be resolved to IDs
+// once and scored without looking up or allocating a single string. The classifiers returned by Compile implement it.
+type IDScorer interface {
+	// ID returns the ID of a word, or UnknownID and false for a word the classifier never learned
+	ID(word string) (uint32, bool)
+	// IDBytes is ID for a word held in bytes, it does not allocate
+	IDBytes(word []byte) (uint32, bool)
+	// ScoresIDs writes the posterior of each category for a document of IDs to posteriors, which needs a slot per
+	// category, and returns the best category. It does not allocate.
+	ScoresIDs(ids []uint32, posteriors []float64) (int, error)
+}
+
+// rowsByID inverts the IDs of the rows of a table
+func rowsByID(ids []uint32) []int32 {
+	max := uint32(0)
+	for _, id := range ids {
+		if id > max {
+			max = id
+		}
+	}
+
+	rows := make([]int32, max+1)
+	for i := range rows {
+		rows[i] = -1
+	}
+	for row, id := range ids {
+		rows[id] = int32(row)
+	}
+	return rows
+}
+
+// id turns a row into the ID of its word
+func (m *compiled) id(row int) (uint32, bool) {
+	if row < 0 {
+		return UnknownID, false
+	}
+
+	if m.Rows == nil {
+		return uint32(row), true
+	}
+	return m.IDs[row], true
+}
+
+// ID returns the ID of a word. When the classifier was compiled from a tree that gives words IDs, such as an arena
+// tree, these are the tree's IDs, which every later compile of the same tree keeps. Otherwise a word's ID is its
+// position in this classifier alone.
+func (m *compiled) ID(word string) (uint32, bool) {
+	return m.id(m.Table.find(word))
+}
+
+// IDBytes is ID for a word held in bytes, it does not allocate
+func (m *compiled) IDBytes(word []byte) (uint32, bool) {
+	n := m.Table.Len()
+	idx := sort.Search(n, func(i int) bool { return string(m.Table.word(i)) >= string(word) })
+	if idx < n && string(m.Table.word(idx)) == string(word) {
+		return m.id(idx)
+	}
+	return UnknownID, false
+}
+
+// ScoresIDs scores a document resolved to IDs, IDs the classifier does not know are scored like unseen words. The
+// shape policy cannot see the shape of a word from its ID, so unknown IDs get the unknown word probabilities.
+func (m *compiled) ScoresIDs(ids []uint32, posteriors []float64) (int, error) {
+	n := m.Table.Categories
+	if len(posteriors) < n {
+		return 0, ErrShortBuffer
+	}
+
+	joint := posteriors[:n]
+	copy(joint, m.LogPriors)
+	for _, id := range ids {
+		row := m.row(id)
+		var logProbs []float64
+		switch {
+		case row >= 0:
+			logProbs = m.Table.row(row)
+		case m.Unknown == UnknownIgnore:
+			continue
+		default:
+			logProbs = m.UnknownLogProbs
+		}
+
+		for i, logProb := range logProbs {
+			joint[i] += logProb
+		}
+	}
+
+	best := 0
+	for i := range joint {
+		if math.IsNaN(joint[i]) {
+			return 0, ErrDegenerateModel
+		}
+		if joint[i] > joint[best] {
+			best = i
+		}
+	}
+
+	max := joint[best]
+	if math.IsInf(max, -1) {
+		return 0, ErrNaN
+	}
+
+	// normalize in log space like Score, so that long documents do not underflow
+	sum := 0.0
+	for i := range joint {
+		joint[i] = math.Exp(joint[i] - max)
+		sum += joint[i]
+	}
+	for i := range joint {
+		joint[i] /= sum
+	}
+	return best, nil
+}
+
+// row returns the row of the word with an ID, or -1 if the ID is not one of this classifier's words
+func (m *compiled) row(id uint32) int {
+	if m.Rows == nil {
+		if int64(id) < int64(m.Table.Len()) {
+			return int(id)
+		}
+		return -1
+	}
+
+	if int64(id) < int64(len(m.Rows)) {
+		return int(m.Rows[id])
+	}
+	return -1
+}
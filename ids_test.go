@@ -0,0 +1,174 @@
+package bayesian
+
+import (
+	"testing"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+	"github.com/stretchr/testify/assert"
+)
+
+// resolve turns a document into IDs the way a caller would, once up front
+func resolve(s IDScorer, doc []string) []uint32 {
+	ids := make([]uint32, len(doc))
+	for i, word := range doc {
+		ids[i], _ = s.ID(word)
+	}
+	return ids
+}
+
+func TestScoresIDs(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithArenaTree()}} {
+		for _, policy := range []UnknownPolicy{UnknownSmooth, UnknownIgnore, UnknownHapax} {
+			c := syntheticCorpus(t, 3, 300, 200, append(opts, WithUnknownPolicy(policy))...)
+			model, err := Compile(c)
+			assert.NoError(t, err)
+			s := model.(IDScorer)
+
+			posteriors := make([]float64, 3)
+			for _, doc := range syntheticDocs(30, 400) {
+				want, err := model.Score(doc)
+				assert.NoError(t, err)
+
+				best, err := s.ScoresIDs(resolve(s, doc), posteriors)
+				assert.NoError(t, err)
+				assert.Equal(t, want.Best, best)
+				assert.InDeltaSlice(t, want.Posteriors, posteriors, 1e-12)
+			}
+
+			_, err = s.ScoresIDs(nil, posteriors[:2])
+			assert.Equal(t, ErrShortBuffer, err)
+		}
+	}
+}
+
+func TestIDs(t *testing.T) {
+	c := syntheticCorpus(t, 2, 300, 100)
+	model, err := Compile(c)
+	assert.NoError(t, err)
+	s := model.(IDScorer)
+
+	seen := make(map[uint32]string)
+	c.(*classifier).Tree.(radix.Walker).Walk(func(word string, counts []int) bool {
+		id, ok := s.ID(word)
+		assert.True(t, ok)
+		byBytes, ok := s.IDBytes([]byte(word))
+		assert.True(t, ok)
+		assert.Equal(t, id, byBytes)
+
+		_, duplicate := seen[id]
+		assert.False(t, duplicate)
+		seen[id] = word
+		return true
+	})
+
+	id, ok := s.ID("never learned")
+	assert.False(t, ok)
+	assert.Equal(t, UnknownID, id)
+	id, ok = s.IDBytes([]byte("never learned"))
+	assert.False(t, ok)
+	assert.Equal(t, UnknownID, id)
+}
+
+func TestIDsStableAcrossCompiles(t *testing.T) {
+	c, err := NewClassifier(2, 1, WithArenaTree())
+	assert.NoError(t, err)
+	assert.NoError(t, c.Learn([]string{"romane", "romanus", "rubens"}, Positive))
+	assert.NoError(t, c.Learn([]string{"meeting", "agenda"}, Negative))
+
+	before, err := Compile(c)
+	assert.NoError(t, err)
+	doc := []string{"romanus", "rubens", "meeting"}
+	ids := resolve(before.(IDScorer), doc)
+
+	// new words land between the old ones, which moves their rows but not their IDs
+	assert.NoError(t, c.Learn([]string{"roman", "romulus", "rubicon", "aardvark", "meetings"}, Negative))
+	after, err := Compile(c)
+	assert.NoError(t, err)
+	assert.Equal(t, ids, resolve(after.(IDScorer), doc))
+
+	want, err := after.Score(doc)
+	assert.NoError(t, err)
+	posteriors := make([]float64, 2)
+	best, err := after.(IDScorer).ScoresIDs(ids, posteriors)
+	assert.NoError(t, err)
+	assert.Equal(t, want.Best, best)
+	assert.InDeltaSlice(t, want.Posteriors, posteriors, 1e-12)
+}
+
+func TestScoresIDsDoesNotAllocate(t *testing.T) {
+	c := syntheticCorpus(t, 4, 2000, 500, WithArenaTree())
+	model, err := Compile(c)
+	assert.NoError(t, err)
+	s := model.(IDScorer)
+
+	words := make([][]byte, 0)
+	for _, word := range syntheticDocs(1, 3000)[0] {
+		words = append(words, []byte(word))
+	}
+	ids := make([]uint32, len(words))
+	posteriors := make([]float64, 4)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for i, word := range words {
+			ids[i], _ = s.IDBytes(word)
+		}
+		_, _ = s.ScoresIDs(ids, posteriors)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkScoresIDs(b *testing.B) {
+	c := syntheticCorpus(b, 4, 5000, 2000, WithArenaTree())
+	model, err := Compile(c)
+	if err != nil {
+		b.Fatal(err)
+	}
+	s := model.(IDScorer)
+
+	docs := syntheticDocs(100, 6000)
+	raw := make([][][]byte, len(docs))
+	for i, doc := range docs {
+		for _, word := range doc {
+			raw[i] = append(raw[i], []byte(word))
+		}
+	}
+
+	b.Run("Score", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := model.Score(docs[i%len(docs)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		ids := make([]uint32, 0, 64)
+		posteriors := make([]float64, 4)
+		for i := 0; i < b.N; i++ {
+			ids = ids[:0]
+			for _, word := range raw[i%len(raw)] {
+				id, _ := s.IDBytes(word)
+				ids = append(ids, id)
+			}
+			if _, err := s.ScoresIDs(ids, posteriors); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	resolved := make([][]uint32, len(docs))
+	for i, doc := range docs {
+		resolved[i] = resolve(s, doc)
+	}
+	b.Run("IDs", func(b *testing.B) {
+		b.ReportAllocs()
+		posteriors := make([]float64, 4)
+		for i := 0; i < b.N; i++ {
+			if _, err := s.ScoresIDs(resolved[i%len(resolved)], posteriors); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
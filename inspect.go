@@ -0,0 +1,102 @@
+package bayesian
+
+import (
+	"container/heap"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// Stats summarizes what a classifier has learned
+type Stats struct {
+	CategoryTotals  []int
+	UniqueWords     int
+	SmoothingFactor float64
+	Smoother        Smoother
+	Unknown         UnknownPolicy
+}
+
+// WordCount is a word along with how many times it was learned in each category
+type WordCount struct {
+	Word   string
+	Counts []int
+}
+
+// Inspector is implemented by classifiers that can describe the model they hold
+type Inspector interface {
+	Stats() Stats
+	TopWords(category int, n int) []WordCount
+}
+
+// Stats reports the category totals, vocabulary size and smoothing factor of this classifier
+func (c *classifier) Stats() Stats {
+	lock := c.lock()
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return Stats{
+		CategoryTotals:  append([]int(nil), c.Tree.GetTotals()...),
+		UniqueWords:     c.Tree.UniqueWords(),
+		SmoothingFactor: c.SmoothingFactor,
+		Smoother:        c.smoother(),
+		Unknown:         c.Unknown,
+	}
+}
+
+// TopWords returns the n words seen most often in a category, most frequent first
+func (c *classifier) TopWords(category int, n int) []WordCount {
+	walker, ok := c.Tree.(radix.Walker)
+	if !ok || n <= 0 || category < 0 || category >= c.Tree.CategoryCount() {
+		return nil
+	}
+
+	lock := c.lock()
+	lock.RLock()
+	defer lock.RUnlock()
+
+	top := &wordHeap{category: category}
+	walker.Walk(func(word string, counts []int) bool {
+		if counts[category] == 0 {
+			return true
+		}
+
+		if top.Len() < n {
+			heap.Push(top, WordCount{Word: word, Counts: append([]int(nil), counts...)})
+		} else if counts[category] > top.words[0].Counts[category] {
+			top.words[0] = WordCount{Word: word, Counts: append([]int(nil), counts...)}
+			heap.Fix(top, 0)
+		}
+		return true
+	})
+
+	words := make([]WordCount, top.Len())
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = heap.Pop(top).(WordCount)
+	}
+	return words
+}
+
+// wordHeap is a min heap of words ordered by their count in one category, ties are broken by the word itself
+type wordHeap struct {
+	category int
+	words    []WordCount
+}
+
+func (h *wordHeap) Len() int { return len(h.words) }
+
+func (h *wordHeap) Less(i, j int) bool {
+	left, right := h.words[i].Counts[h.category], h.words[j].Counts[h.category]
+	if left == right {
+		return h.words[i].Word > h.words[j].Word
+	}
+	return left < right
+}
+
+func (h *wordHeap) Swap(i, j int) { h.words[i], h.words[j] = h.words[j], h.words[i] }
+
+func (h *wordHeap) Push(x interface{}) { h.words = append(h.words, x.(WordCount)) }
+
+func (h *wordHeap) Pop() interface{} {
+	last := h.words[len(h.words)-1]
+	h.words = h.words[:len(h.words)-1]
+	return last
+}
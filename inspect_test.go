@@ -0,0 +1,31 @@
+package bayesian
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect(t *testing.T) {
+	c, err := NewClassifier(2, 0.5)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Learn([]string{"a", "b", "b", "c", "c", "c"}, 0))
+	assert.NoError(t, c.Learn([]string{"c", "d"}, 1))
+
+	inspector := c.(Inspector)
+	stats := inspector.Stats()
+	assert.Equal(t, []int{6, 2}, stats.CategoryTotals)
+	assert.Equal(t, 4, stats.UniqueWords)
+	assert.Equal(t, 0.5, stats.SmoothingFactor)
+
+	top := inspector.TopWords(0, 2)
+	assert.Equal(t, []WordCount{{Word: "c", Counts: []int{3, 1}}, {Word: "b", Counts: []int{2, 0}}}, top)
+
+	top = inspector.TopWords(1, 10)
+	assert.Len(t, top, 2)
+	assert.Equal(t, "c", top[0].Word)
+	assert.Equal(t, "d", top[1].Word)
+
+	assert.Nil(t, inspector.TopWords(2, 1))
+	assert.Nil(t, inspector.TopWords(0, 0))
+}
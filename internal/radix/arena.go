@@ -0,0 +1,344 @@
+package radix
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"math"
+)
+
+// noRow marks an arena node that has never held counts
+const noRow = ^uint32(0)
+
+// maxArena bounds the nodes, rows, edge label bytes and counts of an arena tree, which are all addressed or stored
+// as 32 bit numbers, it is a variable so that tests can lower it
+var maxArena uint64 = math.MaxUint32
+
+// ErrArenaFull is an error for when an arena tree would need more than 32 bits for an index or a count
+var ErrArenaFull = errors.New("radix: arena tree is full")
+
+// arena is a radix tree laid out in a few large slices instead of a heap object per node, so the garbage collector
+// has almost nothing to scan however many words it holds. Nodes are addressed by their index, the counts of every
+// word are rows of one flat slice, and the edge labels are ranges of one shared byte pool. Node 0 is the root, so a
+// child index of 0 means there is none. Edges are split on bytes rather than runes, which only changes the shape of
+// the tree, not what it holds.
+type arena struct {
+	NumCategories    int
+	CategoryTotals   []int
+	CategoryTypes    []int
+	UniqueWordsCount int
+	Nodes            []arenaNode
+	// Counts holds a row of NumCategories counts for each node that was ever a word
+	Counts []uint32
+	// Prefixes holds the edge labels, splitting an edge only changes which range of it the nodes refer to
+	Prefixes []byte
+}
+
+// arenaNode is a node of an arena tree, its children are a linked list in the order of their first byte
+type arenaNode struct {
+	PrefixStart uint32
+	PrefixLen   uint32
+	FirstChild  uint32
+	NextSibling uint32
+	Row         uint32
+	IsLeaf      bool
+}
+
+func init() {
+	gob.Register(&arena{})
+}
+
+// NewArena creates a radix tree whose nodes, counts and edge labels live in a few large slices
+func NewArena(numCategories int) (Tree, error) {
+	if numCategories <= 0 {
+		return nil, ErrInvalidCategoryCount
+	}
+
+	return &arena{
+		NumCategories:  numCategories,
+		CategoryTotals: make([]int, numCategories, numCategories),
+		CategoryTypes:  make([]int, numCategories, numCategories),
+		Nodes:          []arenaNode{{Row: noRow}},
+	}, nil
+}
+
+// newNode appends a node with the given edge label and returns its index
+func (t *arena) newNode(start uint32, length uint32) uint32 {
+	t.Nodes = append(t.Nodes, arenaNode{PrefixStart: start, PrefixLen: length, Row: noRow})
+	return uint32(len(t.Nodes) - 1)
+}
+
+// prefix returns the edge label of a node, it must not be kept across inserts
+func (t *arena) prefix(n uint32) []byte {
+	node := &t.Nodes[n]
+	return t.Prefixes[node.PrefixStart : node.PrefixStart+node.PrefixLen]
+}
+
+// row returns the counts of a node, it must not be kept across inserts
+func (t *arena) row(n uint32) []uint32 {
+	start := int(t.Nodes[n].Row) * t.NumCategories
+	return t.Counts[start : start+t.NumCategories]
+}
+
+// child finds the child of a node whose edge starts with b, along with the child before where it is or would be
+func (t *arena) child(n uint32, b byte) (uint32, uint32) {
+	prev := uint32(0)
+	for c := t.Nodes[n].FirstChild; c != 0; c = t.Nodes[c].NextSibling {
+		first := t.Prefixes[t.Nodes[c].PrefixStart]
+		if first == b {
+			return c, prev
+		}
+		if first > b {
+			break
+		}
+		prev = c
+	}
+	return 0, prev
+}
+
+// link puts a node into the children of a parent after prev, or first if prev is 0
+func (t *arena) link(parent uint32, prev uint32, n uint32) {
+	if prev == 0 {
+		t.Nodes[n].NextSibling = t.Nodes[parent].FirstChild
+		t.Nodes[parent].FirstChild = n
+		return
+	}
+	t.Nodes[n].NextSibling = t.Nodes[prev].NextSibling
+	t.Nodes[prev].NextSibling = n
+}
+
+// matched returns how many bytes of a node's edge label the needle starts with
+func (t *arena) matched(n uint32, needle string) int {
+	prefix := t.prefix(n)
+	i := 0
+	for i < len(prefix) && i < len(needle) && prefix[i] == needle[i] {
+		i++
+	}
+	return i
+}
+
+// find returns the node representing the needle, if there is one
+func (t *arena) find(needle string) (uint32, bool) {
+	current := uint32(0)
+	for needle != "" {
+		c, _ := t.child(current, needle[0])
+		if c == 0 || t.matched(c, needle) < int(t.Nodes[c].PrefixLen) {
+			return 0, false
+		}
+		needle = needle[t.Nodes[c].PrefixLen:]
+		current = c
+	}
+	return current, t.Nodes[current].IsLeaf
+}
+
+// findBytes is find for a needle held in bytes
+func (t *arena) findBytes(needle []byte) (uint32, bool) {
+	current := uint32(0)
+	for len(needle) > 0 {
+		c, _ := t.child(current, needle[0])
+		if c == 0 {
+			return 0, false
+		}
+
+		length := int(t.Nodes[c].PrefixLen)
+		if len(needle) < length || !bytes.Equal(t.prefix(c), needle[:length]) {
+			return 0, false
+		}
+		needle = needle[length:]
+		current = c
+	}
+	return current, t.Nodes[current].IsLeaf
+}
+
+// findOrCreate returns the node representing the needle, creating it and splitting edges as needed. It fails
+// before changing anything once there is no room for another node or for the needle's bytes.
+func (t *arena) findOrCreate(needle string) (uint32, error) {
+	// a needle needs at most two new nodes, the edge split where it leaves the tree and its own
+	if uint64(len(t.Nodes))+2 > maxArena || uint64(len(t.Prefixes))+uint64(len(needle)) > maxArena {
+		if n, found := t.find(needle); found || n != 0 || needle == "" {
+			return n, nil
+		}
+		return 0, ErrArenaFull
+	}
+
+	current := uint32(0)
+	for needle != "" {
+		c, prev := t.child(current, needle[0])
+		if c == 0 {
+			// no edge shares a byte with the needle, so the rest of it becomes a new edge
+			start := uint32(len(t.Prefixes))
+			t.Prefixes = append(t.Prefixes, needle...)
+			n := t.newNode(start, uint32(len(needle)))
+			t.link(current, prev, n)
+			return n, nil
+		}
+
+		matched := t.matched(c, needle)
+		if matched < int(t.Nodes[c].PrefixLen) {
+			// the needle leaves the edge part way, so the edge is split by a node in the child's place
+			m := t.newNode(t.Nodes[c].PrefixStart, uint32(matched))
+			t.Nodes[m].NextSibling = t.Nodes[c].NextSibling
+			if prev == 0 {
+				t.Nodes[current].FirstChild = m
+			} else {
+				t.Nodes[prev].NextSibling = m
+			}
+
+			t.Nodes[c].PrefixStart += uint32(matched)
+			t.Nodes[c].PrefixLen -= uint32(matched)
+			t.Nodes[c].NextSibling = 0
+			t.Nodes[m].FirstChild = c
+			c = m
+		}
+
+		needle = needle[matched:]
+		current = c
+	}
+	return current, nil
+}
+
+// Insert creates or finds the node of the word and increments the category
+func (t *arena) Insert(needle string, category int) error {
+	if category < 0 || category >= t.NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+
+	n, err := t.findOrCreate(needle)
+	if err != nil {
+		return err
+	}
+
+	if t.Nodes[n].Row == noRow {
+		// the row index has to stay below noRow
+		rows := len(t.Counts) / t.NumCategories
+		if uint64(rows)+1 >= maxArena {
+			return ErrArenaFull
+		}
+
+		t.Nodes[n].Row = uint32(rows)
+		for i := 0; i < t.NumCategories; i++ {
+			t.Counts = append(t.Counts, 0)
+		}
+	} else if uint64(t.row(n)[category]) >= maxArena {
+		return ErrArenaFull
+	}
+
+	if !t.Nodes[n].IsLeaf {
+		t.Nodes[n].IsLeaf = true
+		t.UniqueWordsCount++
+	}
+
+	row := t.row(n)
+	row[category]++
+	if row[category] == 1 {
+		t.CategoryTypes[category]++
+	}
+	t.CategoryTotals[category]++
+	return nil
+}
+
+// Remove decrements the count of a word in a category, the word stops being counted as unique once it has no counts
+func (t *arena) Remove(needle string, category int) error {
+	if category < 0 || category >= t.NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+
+	n, found := t.find(needle)
+	if !found || t.row(n)[category] == 0 {
+		return ErrNotFound
+	}
+
+	row := t.row(n)
+	row[category]--
+	t.CategoryTotals[category]--
+	if row[category] == 0 {
+		t.CategoryTypes[category]--
+	}
+
+	for _, count := range row {
+		if count != 0 {
+			return nil
+		}
+	}
+
+	// the node keeps its place and its row, it just stops representing a word
+	t.Nodes[n].IsLeaf = false
+	t.UniqueWordsCount--
+	return nil
+}
+
+// Find gets the category values associated with a given string
+func (t *arena) Find(needle string) ([]int, bool) {
+	n, found := t.find(needle)
+	if !found {
+		return nil, false
+	}
+	return t.counts(n), true
+}
+
+// counts copies the row of a node into whole counts
+func (t *arena) counts(n uint32) []int {
+	row := t.row(n)
+	counts := make([]int, len(row), len(row))
+	for i, count := range row {
+		counts[i] = int(count)
+	}
+	return counts
+}
+
+// GetTotals fetches the totals associated with each category
+func (t *arena) GetTotals() []int {
+	return t.CategoryTotals
+}
+
+// CategoryCount returns the number of categories we are tracking in this tree
+func (t *arena) CategoryCount() int {
+	return t.NumCategories
+}
+
+// UniqueWords returns the number of words represented in this tree
+func (t *arena) UniqueWords() int {
+	return t.UniqueWordsCount
+}
+
+// CategoryUniqueWords returns the number of distinct words seen in each category
+func (t *arena) CategoryUniqueWords() []int {
+	return t.CategoryTypes
+}
+
+// Walk visits every word in the tree in lexical order
+func (t *arena) Walk(fn func(word string, counts []int) bool) {
+	t.walk(0, nil, fn)
+}
+
+// walk does a depth first traversal below a node, and reports whether the traversal should continue
+func (t *arena) walk(n uint32, word []byte, fn func(word string, counts []int) bool) bool {
+	if t.Nodes[n].IsLeaf && !fn(string(word), t.counts(n)) {
+		return false
+	}
+
+	for c := t.Nodes[n].FirstChild; c != 0; c = t.Nodes[c].NextSibling {
+		if !t.walk(c, append(word, t.prefix(c)...), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// ID returns the index of the word's node, nodes are never moved or reused so the ID never changes
+func (t *arena) ID(word string) (uint32, bool) {
+	return t.find(word)
+}
+
+// IDBytes is ID for a word held in bytes, it does not allocate
+func (t *arena) IDBytes(word []byte) (uint32, bool) {
+	return t.findBytes(word)
+}
+
+// FindID gets the category values of the word with an ID
+func (t *arena) FindID(id uint32) ([]int, bool) {
+	if int(id) >= len(t.Nodes) || !t.Nodes[id].IsLeaf {
+		return nil, false
+	}
+	return t.counts(id), true
+}
@@ -0,0 +1,257 @@
+package radix
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestArena(t *testing.T, categories int) Tree {
+	tree, err := NewArena(categories)
+	assert.NoError(t, err)
+	return tree
+}
+
+func TestArenaTree(t *testing.T) {
+	runTreeTests(t, newTestArena)
+
+	_, err := NewArena(0)
+	assert.Equal(t, ErrInvalidCategoryCount, err)
+}
+
+func TestArenaSplitsAndEmptyWord(t *testing.T) {
+	tree := newTestArena(t, 2)
+	for _, word := range []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus", "r", ""} {
+		assert.NoError(t, tree.Insert(word, 1))
+	}
+	assert.NoError(t, tree.Insert("rom", 0))
+
+	for _, word := range []string{"ro", "roman", "rubi", "x"} {
+		_, found := tree.Find(word)
+		assert.False(t, found, word)
+	}
+
+	counts, found := tree.Find("")
+	assert.True(t, found)
+	assert.Equal(t, []int{0, 1}, counts)
+	counts, found = tree.Find("rom")
+	assert.True(t, found)
+	assert.Equal(t, []int{1, 0}, counts)
+	assert.Equal(t, 10, tree.UniqueWords())
+
+	var words []string
+	tree.(Walker).Walk(func(word string, counts []int) bool {
+		words = append(words, word)
+		return true
+	})
+	assert.Equal(t, []string{"", "r", "rom", "romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus"},
+		words)
+}
+
+func TestArenaFull(t *testing.T) {
+	defer func(max uint64) { maxArena = max }(maxArena)
+	maxArena = 10
+
+	tree := newTestArena(t, 2)
+	assert.NoError(t, tree.Insert("apple", 0))
+	assert.NoError(t, tree.Insert("apply", 1))
+
+	// "banana" does not fit in what is left of the label bytes, and nothing is changed trying
+	nodes, prefixes := len(tree.(*arena).Nodes), len(tree.(*arena).Prefixes)
+	assert.Equal(t, ErrArenaFull, tree.Insert("banana", 0))
+	assert.Equal(t, nodes, len(tree.(*arena).Nodes))
+	assert.Equal(t, prefixes, len(tree.(*arena).Prefixes))
+	assert.Equal(t, []int{1, 1}, tree.GetTotals())
+
+	// words already in the tree can still be counted, up to the largest count
+	assert.NoError(t, tree.Insert("appl", 0))
+	for i := 1; i < 10; i++ {
+		assert.NoError(t, tree.Insert("apple", 0))
+	}
+	assert.Equal(t, ErrArenaFull, tree.Insert("apple", 0))
+	counts, _ := tree.Find("apple")
+	assert.Equal(t, []int{10, 0}, counts)
+}
+
+func TestArenaMatchesMemory(t *testing.T) {
+	memory, err := New(3)
+	assert.NoError(t, err)
+	tree := newTestArena(t, 3)
+
+	rng := rand.New(rand.NewSource(1))
+	var words []string
+	for i := 0; i < 20000; i++ {
+		category := rng.Intn(3)
+		if len(words) > 0 && rng.Intn(4) == 0 {
+			word := words[rng.Intn(len(words))]
+			assert.Equal(t, memory.(Remover).Remove(word, category), tree.(Remover).Remove(word, category))
+			continue
+		}
+
+		word := randString()
+		words = append(words, word)
+		assert.NoError(t, memory.Insert(word, category))
+		assert.NoError(t, tree.Insert(word, category))
+	}
+
+	assert.Equal(t, memory.UniqueWords(), tree.UniqueWords())
+	assert.Equal(t, memory.GetTotals(), tree.GetTotals())
+	assert.Equal(t, memory.(TypeCounter).CategoryUniqueWords(), tree.(TypeCounter).CategoryUniqueWords())
+
+	var want, got []string
+	memory.(Walker).Walk(func(word string, counts []int) bool {
+		want = append(want, word)
+		return true
+	})
+	tree.(Walker).Walk(func(word string, counts []int) bool {
+		got = append(got, word)
+		found, ok := memory.Find(word)
+		assert.True(t, ok)
+		assert.Equal(t, found, counts)
+		return true
+	})
+	assert.Equal(t, want, got)
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(&tree))
+	var decoded Tree
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	assert.Equal(t, tree, decoded)
+}
+
+// benchmarkWords makes n distinct words with the long shared prefixes of a real vocabulary
+func benchmarkWords(n int) []string {
+	rng := rand.New(rand.NewSource(1))
+	stems := []string{"inter", "trans", "under", "over", "counter", "micro", "re", "pre", "un", "dis"}
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fmt.Sprintf("%s%x", stems[rng.Intn(len(stems))], rng.Int63())
+	}
+	return words
+}
+
+var treeLayouts = []struct {
+	name string
+	new  func(int) (Tree, error)
+}{{"Pointer", New}, {"Arena", NewArena}}
+
+func buildTree(b *testing.B, newTree func(int) (Tree, error), words []string) Tree {
+	tree, err := newTree(4)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i, word := range words {
+		if err := tree.Insert(word, i%4); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return tree
+}
+
+func BenchmarkLayoutInsert(b *testing.B) {
+	words := benchmarkWords(100000)
+	for _, layout := range treeLayouts {
+		b.Run(layout.name, func(b *testing.B) {
+			b.ReportAllocs()
+			tree, _ := layout.new(4)
+			for i := 0; i < b.N; i++ {
+				_ = tree.Insert(words[i%len(words)], i%4)
+			}
+		})
+	}
+}
+
+func BenchmarkLayoutFind(b *testing.B) {
+	words := benchmarkWords(100000)
+	for _, layout := range treeLayouts {
+		b.Run(layout.name, func(b *testing.B) {
+			tree := buildTree(b, layout.new, words)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tree.Find(words[i%len(words)])
+			}
+		})
+	}
+}
+
+// BenchmarkLayoutGC times a full collection with a million word tree live, and reports the heap the tree holds and
+// how many objects the collector has to trace
+func BenchmarkLayoutGC(b *testing.B) {
+	words := benchmarkWords(1000000)
+	for _, layout := range treeLayouts {
+		b.Run(layout.name, func(b *testing.B) {
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			tree := buildTree(b, layout.new, words)
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/(1<<20), "heap-MB")
+			b.ReportMetric(float64(after.HeapObjects-before.HeapObjects), "objects")
+			runtime.KeepAlive(tree)
+		})
+	}
+}
+
+func TestArenaIDs(t *testing.T) {
+	tree := newTestArena(t, 2)
+	vocabulary := tree.(Vocabulary)
+	assert.NoError(t, tree.Insert("romanus", 0))
+	assert.NoError(t, tree.Insert("rubens", 1))
+
+	romanus, ok := vocabulary.ID("romanus")
+	assert.True(t, ok)
+	rubens, ok := vocabulary.IDBytes([]byte("rubens"))
+	assert.True(t, ok)
+	assert.NotEqual(t, romanus, rubens)
+
+	// splitting the edges above a word keeps its ID
+	for _, word := range []string{"roman", "romulus", "r", "rubicon", "rubensian"} {
+		assert.NoError(t, tree.Insert(word, 0))
+	}
+	id, ok := vocabulary.ID("romanus")
+	assert.True(t, ok)
+	assert.Equal(t, romanus, id)
+	id, ok = vocabulary.IDBytes([]byte("rubens"))
+	assert.True(t, ok)
+	assert.Equal(t, rubens, id)
+
+	counts, ok := vocabulary.FindID(rubens)
+	assert.True(t, ok)
+	assert.Equal(t, []int{0, 1}, counts)
+
+	// interior nodes and removed words have no counts, and a removed word gets its ID back
+	_, ok = vocabulary.ID("rom")
+	assert.False(t, ok)
+	_, ok = vocabulary.IDBytes([]byte("rubensia"))
+	assert.False(t, ok)
+	_, ok = vocabulary.FindID(1 << 30)
+	assert.False(t, ok)
+
+	assert.NoError(t, tree.(Remover).Remove("romanus", 0))
+	_, ok = vocabulary.FindID(romanus)
+	assert.False(t, ok)
+	assert.NoError(t, tree.Insert("romanus", 1))
+	id, ok = vocabulary.ID("romanus")
+	assert.True(t, ok)
+	assert.Equal(t, romanus, id)
+
+	word := []byte("rubicon")
+	allocs := testing.AllocsPerRun(100, func() {
+		vocabulary.IDBytes(word)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
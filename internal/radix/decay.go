@@ -0,0 +1,322 @@
+package radix
+
+import (
+	"encoding/gob"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrInvalidHalfLife is an error for when the half-life of a decayed tree is not positive
+var ErrInvalidHalfLife = errors.New("radix: invalid half-life")
+
+// Decayer is implemented by trees whose counts fade exponentially over time, their counts are real valued and depend
+// on when they are read
+type Decayer interface {
+	// Decay moves the tree's clock forward to now, what is inserted afterwards is counted as of now
+	Decay(now time.Time)
+	// Clock returns the time of the tree's clock
+	Clock() time.Time
+	// HalfLife returns how long it takes a count to fade to half
+	HalfLife() time.Duration
+	// FindAt returns the counts of a word as of now, or as of the clock if now is before it
+	FindAt(needle string, now time.Time) ([]float64, bool)
+	// TotalsAt returns the totals of each category as of now, or as of the clock if now is before it
+	TotalsAt(now time.Time) []float64
+}
+
+// decayed is a tree whose counts halve every half-life. Rather than sweeping every word as time passes, each word
+// remembers when its counts were last updated, and they are decayed from then whenever they are read or changed. The
+// words are kept in radix nodes whose Values hold the index of the word's counts in Slots, so the nodes are shared
+// with the plain tree. Words whose counts have faded away are pruned at most once a half-life, as the clock moves.
+type decayed struct {
+	NumCategories int
+	Life          time.Duration
+	// Now is the clock of the tree in nanoseconds since the Unix epoch
+	Now              int64
+	Root             *node
+	UniqueWordsCount int
+	Totals           decayedCounts
+	// Slots are the counts of the words, Free are the slots of forgotten words waiting to be reused
+	Slots []decayedCounts
+	Free  []int
+	// Swept is the clock when faded words were last pruned
+	Swept int64
+}
+
+// decayedCounts are counts as of the time they were last updated
+type decayedCounts struct {
+	Counts  []float64
+	Updated int64
+}
+
+// minDecayedCount is the count below which a word has faded away, it takes ten half-lives for a single count
+const minDecayedCount = 1.0 / 1024
+
+func init() {
+	gob.Register(&decayed{})
+}
+
+// NewDecayed creates a tree whose counts halve every halfLife, with its clock set to now
+func NewDecayed(numCategories int, halfLife time.Duration, now time.Time) (Tree, error) {
+	if numCategories <= 0 {
+		return nil, ErrInvalidCategoryCount
+	}
+
+	if halfLife <= 0 {
+		return nil, ErrInvalidHalfLife
+	}
+
+	return &decayed{
+		NumCategories: numCategories,
+		Life:          halfLife,
+		Now:           now.UnixNano(),
+		Root:          &node{},
+		Totals:        decayedCounts{Counts: make([]float64, numCategories, numCategories), Updated: now.UnixNano()},
+		Swept:         now.UnixNano(),
+	}, nil
+}
+
+// factor is how much a count updated at one time has faded by another
+func (t *decayed) factor(updated int64, now int64) float64 {
+	if now <= updated {
+		return 1
+	}
+	return math.Exp2(-float64(now-updated) / float64(t.Life))
+}
+
+// at returns the time counts should be read at, which is never before the clock, the zero time reads at the clock
+func (t *decayed) at(now time.Time) int64 {
+	if now.IsZero() {
+		return t.Now
+	}
+
+	if ns := now.UnixNano(); ns > t.Now {
+		return ns
+	}
+	return t.Now
+}
+
+// decayed returns the counts as of now
+func (t *decayed) decayed(c *decayedCounts, now int64) []float64 {
+	f := t.factor(c.Updated, now)
+	counts := make([]float64, len(c.Counts), len(c.Counts))
+	for i, count := range c.Counts {
+		counts[i] = count * f
+	}
+	return counts
+}
+
+// add decays the counts to the clock and adds delta to a category, a count never goes below zero
+func (t *decayed) add(c *decayedCounts, category int, delta float64) {
+	if c.Updated != t.Now {
+		c.Counts = t.decayed(c, t.Now)
+		c.Updated = t.Now
+	}
+
+	c.Counts[category] += delta
+	if c.Counts[category] < 0 {
+		c.Counts[category] = 0
+	}
+}
+
+// faded reports whether every count has faded below minDecayedCount by the clock
+func (t *decayed) faded(c *decayedCounts) bool {
+	for _, count := range t.decayed(c, t.Now) {
+		if count >= minDecayedCount {
+			return false
+		}
+	}
+	return true
+}
+
+// find returns the node of a word, or nil when the tree does not hold it
+func (t *decayed) find(needle string) *node {
+	n := t.Root.lookup(needle)
+	if n == nil || !n.IsLeaf {
+		return nil
+	}
+	return n
+}
+
+// Insert adds one to the word in a category as of the clock
+func (t *decayed) Insert(needle string, category int) error {
+	if category < 0 || category >= t.NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+
+	n, isNew := t.Root.findOrCreate(needle)
+	if n.Values == nil {
+		n.Values = []int{t.slot()}
+	}
+	if isNew {
+		t.UniqueWordsCount++
+	}
+
+	t.add(t.counts(n), category, 1)
+	t.add(&t.Totals, category, 1)
+	return nil
+}
+
+// Remove takes one off the word in a category as of the clock, the word is forgotten once its counts have faded
+// away, its node stays in place until the next prune
+func (t *decayed) Remove(needle string, category int) error {
+	if category < 0 || category >= t.NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+
+	n := t.find(needle)
+	if n == nil || t.decayed(t.counts(n), t.Now)[category] <= 0 {
+		return ErrNotFound
+	}
+
+	t.add(t.counts(n), category, -1)
+	t.add(&t.Totals, category, -1)
+	if t.faded(t.counts(n)) {
+		t.forget(n)
+	}
+	return nil
+}
+
+// forget stops a node from being a word, taking what is left of its counts off the totals
+func (t *decayed) forget(n *node) {
+	for i, count := range t.decayed(t.counts(n), t.Now) {
+		t.add(&t.Totals, i, -count)
+	}
+
+	t.Slots[n.Values[0]] = decayedCounts{}
+	t.Free = append(t.Free, n.Values[0])
+	n.IsLeaf = false
+	n.Values = nil
+	t.UniqueWordsCount--
+}
+
+// slot returns an empty slot for the counts of a new word, reusing the slot of a forgotten one when there is one
+func (t *decayed) slot() int {
+	counts := decayedCounts{Counts: make([]float64, t.NumCategories, t.NumCategories), Updated: t.Now}
+	if last := len(t.Free) - 1; last >= 0 {
+		i := t.Free[last]
+		t.Free = t.Free[:last]
+		t.Slots[i] = counts
+		return i
+	}
+
+	t.Slots = append(t.Slots, counts)
+	return len(t.Slots) - 1
+}
+
+// counts returns the counts of a word's node
+func (t *decayed) counts(n *node) *decayedCounts {
+	return &t.Slots[n.Values[0]]
+}
+
+// Find returns the counts of a word as of the clock, rounded to whole counts
+func (t *decayed) Find(needle string) ([]int, bool) {
+	counts, found := t.FindAt(needle, time.Time{})
+	if !found {
+		return nil, false
+	}
+	return round(counts), true
+}
+
+// FindAt returns the counts of a word as of now, or as of the clock if now is before it
+func (t *decayed) FindAt(needle string, now time.Time) ([]float64, bool) {
+	n := t.find(needle)
+	if n == nil {
+		return nil, false
+	}
+	return t.decayed(t.counts(n), t.at(now)), true
+}
+
+// FindFuzzy finds the words near a needle, with their counts as of the clock rounded to whole counts
+func (t *decayed) FindFuzzy(needle string, maxEdits int) []FuzzyMatch {
+	matches := (&root{Root: t.Root}).FindFuzzy(needle, maxEdits)
+	for i := range matches {
+		matches[i].Counts, _ = t.Find(matches[i].Word)
+	}
+	return matches
+}
+
+// Walk visits every word in lexical order with its counts as of the clock, rounded to whole counts
+func (t *decayed) Walk(fn func(word string, counts []int) bool) {
+	t.Root.visit("", func(word string, leaf *node) bool {
+		return fn(word, round(t.decayed(t.counts(leaf), t.Now)))
+	})
+}
+
+// GetTotals fetches the totals of each category as of the clock, rounded to whole counts
+func (t *decayed) GetTotals() []int {
+	return round(t.TotalsAt(time.Time{}))
+}
+
+// TotalsAt returns the totals of each category as of now, or as of the clock if now is before it
+func (t *decayed) TotalsAt(now time.Time) []float64 {
+	return t.decayed(&t.Totals, t.at(now))
+}
+
+// CategoryCount returns the number of categories we are tracking in this tree
+func (t *decayed) CategoryCount() int {
+	return t.NumCategories
+}
+
+// UniqueWords returns the number of words whose counts have not been pruned or removed
+func (t *decayed) UniqueWords() int {
+	return t.UniqueWordsCount
+}
+
+// Decay moves the clock forward to now, it never moves it back. Once a half-life has passed since the last prune,
+// the words that faded away are pruned.
+func (t *decayed) Decay(now time.Time) {
+	t.Now = t.at(now)
+	if t.Now-t.Swept >= int64(t.Life) {
+		t.prune(t.Root)
+		t.Swept = t.Now
+	}
+}
+
+// prune forgets the faded words below a node and drops the nodes left without a word, it reports whether the node
+// itself can be dropped
+func (t *decayed) prune(n *node) bool {
+	if n.IsLeaf && t.faded(t.counts(n)) {
+		t.forget(n)
+	}
+
+	children := n.Children[:0]
+	for _, c := range n.Children {
+		if t.prune(c.Node) {
+			continue
+		}
+
+		// a node that is no longer a word and has a single child is merged into the edge leading to it
+		if !c.Node.IsLeaf && len(c.Node.Children) == 1 {
+			only := c.Node.Children[0]
+			c = child{Prefix: c.Prefix + only.Prefix, Node: only.Node}
+		}
+		children = append(children, c)
+	}
+
+	if len(children) == 0 {
+		children = nil
+	}
+	n.Children = children
+	return !n.IsLeaf && len(n.Children) == 0
+}
+
+// Clock returns the time of the tree's clock
+func (t *decayed) Clock() time.Time {
+	return time.Unix(0, t.Now)
+}
+
+// HalfLife returns how long it takes a count to fade to half
+func (t *decayed) HalfLife() time.Duration {
+	return t.Life
+}
+
+// round rounds real valued counts to whole ones
+func round(counts []float64) []int {
+	rounded := make([]int, len(counts), len(counts))
+	for i, count := range counts {
+		rounded[i] = int(math.Round(count))
+	}
+	return rounded
+}
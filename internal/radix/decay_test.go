@@ -0,0 +1,153 @@
+package radix
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDecayed(t *testing.T) {
+	now := time.Unix(1000, 0)
+	_, err := NewDecayed(0, time.Hour, now)
+	assert.Equal(t, ErrInvalidCategoryCount, err)
+	_, err = NewDecayed(2, 0, now)
+	assert.Equal(t, ErrInvalidHalfLife, err)
+
+	tree, err := NewDecayed(2, time.Hour, now)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrOutOfBoundsCategory, tree.Insert("word", 2))
+	assert.Equal(t, time.Hour, tree.(Decayer).HalfLife())
+	assert.True(t, now.Equal(tree.(Decayer).Clock()))
+}
+
+func TestDecayedCounts(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tree, err := NewDecayed(2, time.Hour, start)
+	assert.NoError(t, err)
+	decayer := tree.(Decayer)
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, tree.Insert("old", 0))
+	}
+	assert.NoError(t, tree.Insert("old", 1))
+
+	// reading ahead of the clock decays without changing anything
+	counts, found := decayer.FindAt("old", start.Add(time.Hour))
+	assert.True(t, found)
+	assert.InDeltaSlice(t, []float64{2, 0.5}, counts, 1e-9)
+	counts, _ = decayer.FindAt("old", start.Add(-time.Hour))
+	assert.InDeltaSlice(t, []float64{4, 1}, counts, 1e-9)
+
+	decayer.Decay(start.Add(2 * time.Hour))
+	assert.NoError(t, tree.Insert("new", 0))
+
+	counts, _ = decayer.FindAt("old", time.Time{})
+	assert.InDeltaSlice(t, []float64{1, 0.25}, counts, 1e-9)
+	counts, _ = decayer.FindAt("new", time.Time{})
+	assert.InDeltaSlice(t, []float64{1, 0}, counts, 1e-9)
+	assert.InDeltaSlice(t, []float64{2, 0.25}, decayer.TotalsAt(time.Time{}), 1e-9)
+
+	// the totals decay together with the words
+	assert.InDeltaSlice(t, []float64{0.5, 0.0625}, decayer.TotalsAt(start.Add(4*time.Hour)), 1e-9)
+
+	// whole counts are rounded
+	rounded, found := tree.Find("old")
+	assert.True(t, found)
+	assert.Equal(t, []int{1, 0}, rounded)
+	assert.Equal(t, []int{2, 0}, tree.GetTotals())
+	assert.Equal(t, 2, tree.UniqueWords())
+
+	// the clock never moves back
+	decayer.Decay(start)
+	assert.True(t, start.Add(2*time.Hour).Equal(decayer.Clock()))
+
+	// learning again brings the word up to the clock before counting
+	assert.NoError(t, tree.Insert("old", 1))
+	counts, _ = decayer.FindAt("old", time.Time{})
+	assert.InDeltaSlice(t, []float64{1, 1.25}, counts, 1e-9)
+}
+
+func TestDecayedWalkAndRemove(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tree, err := NewDecayed(2, time.Hour, start)
+	assert.NoError(t, err)
+
+	for _, word := range []string{"tea", "team", "ten", "team"} {
+		assert.NoError(t, tree.Insert(word, 0))
+	}
+	assert.NoError(t, tree.Insert("ten", 1))
+
+	var words []string
+	var counts [][]int
+	tree.(Walker).Walk(func(word string, c []int) bool {
+		words = append(words, word)
+		counts = append(counts, c)
+		return true
+	})
+	assert.Equal(t, []string{"tea", "team", "ten"}, words)
+	assert.Equal(t, [][]int{{1, 0}, {2, 0}, {1, 1}}, counts)
+
+	matches := tree.(FuzzyFinder).FindFuzzy("tem", 1)
+	assert.Equal(t, []FuzzyMatch{
+		{Word: "tea", Counts: []int{1, 0}, Distance: 1},
+		{Word: "team", Counts: []int{2, 0}, Distance: 1},
+		{Word: "ten", Counts: []int{1, 1}, Distance: 1},
+	}, matches)
+
+	remover := tree.(Remover)
+	assert.Equal(t, ErrNotFound, remover.Remove("te", 0))
+	assert.Equal(t, ErrNotFound, remover.Remove("tea", 1))
+	assert.Equal(t, ErrOutOfBoundsCategory, remover.Remove("tea", 2))
+
+	// removing what was inserted at the same clock takes it back exactly
+	assert.NoError(t, remover.Remove("tea", 0))
+	_, found := tree.Find("tea")
+	assert.False(t, found)
+	assert.Equal(t, 2, tree.UniqueWords())
+	assert.InDeltaSlice(t, []float64{3, 1}, tree.(Decayer).TotalsAt(time.Time{}), 1e-9)
+
+	assert.NoError(t, tree.Insert("tea", 1))
+	counts2, found := tree.Find("tea")
+	assert.True(t, found)
+	assert.Equal(t, []int{0, 1}, counts2)
+	assert.Equal(t, 3, tree.UniqueWords())
+}
+
+func TestDecayedPrunesFadedWords(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tree, err := NewDecayed(1, time.Hour, start)
+	assert.NoError(t, err)
+	decayer := tree.(Decayer)
+
+	for _, word := range []string{"alpha", "alps", "beta"} {
+		assert.NoError(t, tree.Insert(word, 0))
+	}
+
+	// nothing is pruned before a half-life has passed, and nothing that has not faded away
+	decayer.Decay(start.Add(30 * time.Minute))
+	assert.Equal(t, 3, tree.UniqueWords())
+	decayer.Decay(start.Add(9 * time.Hour))
+	assert.NoError(t, tree.Insert("alps", 0))
+	assert.Equal(t, 3, tree.UniqueWords())
+
+	// over ten half-lives later only the word learned again is left, in a tree without the pruned nodes
+	decayer.Decay(start.Add(11 * time.Hour))
+	assert.Equal(t, 1, tree.UniqueWords())
+	_, found := tree.Find("alpha")
+	assert.False(t, found)
+	counts, found := decayer.FindAt("alps", time.Time{})
+	assert.True(t, found)
+	assert.InDeltaSlice(t, []float64{0.25}, counts, 1e-3)
+	assert.InDeltaSlice(t, counts, decayer.TotalsAt(time.Time{}), 1e-9)
+
+	root := tree.(*decayed).Root
+	assert.Equal(t, []child{{Prefix: "alps", Node: root.Children[0].Node}}, root.Children)
+	assert.Empty(t, root.Children[0].Node.Children)
+
+	// a word can be learned again after it was pruned, in the slot of a pruned word
+	assert.NoError(t, tree.Insert("alpha", 0))
+	assert.Equal(t, 2, tree.UniqueWords())
+	assert.Len(t, tree.(*decayed).Slots, 3)
+	assert.Len(t, tree.(*decayed).Free, 1)
+}
@@ -0,0 +1,930 @@
+package radix
+
+import (
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"os"
+	"sort"
+	"sync"
+)
+
+// DefaultPageSize is the size of the pages of a disk tree
+const DefaultPageSize = 4096
+
+// minCachePages is the fewest pages a disk tree keeps in memory, enough for a few root to leaf paths
+const minCachePages = 16
+
+const (
+	diskMagic = "RADIXDB1"
+
+	// pages 0 and 1 hold the two most recent commits, the newest valid one is used when the file is opened
+	metaPages = 2
+	// metaHeader is the size of the fixed part of a meta page, before the totals, distinct word counts and metadata
+	metaHeader = 48
+
+	// longWordSuffix is the separator and hex hash that end the key of a word too long for a page
+	longWordSuffix = 17
+
+	leafPage   = 1
+	branchPage = 2
+	// pageHeader is the kind of the page and its number of keys
+	pageHeader = 3
+)
+
+// ErrCorrupt is an error for when a disk tree's file cannot be read back
+var ErrCorrupt = errors.New("radix: corrupt disk tree")
+
+// ErrMetadataTooLarge is an error for when the metadata of a disk tree does not fit in its meta page
+var ErrMetadataTooLarge = errors.New("radix: metadata too large")
+
+// ErrClosed is an error for when a disk tree is used after it was closed
+var ErrClosed = errors.New("radix: disk tree is closed")
+
+// DiskTree is a Tree stored in a single file, for vocabularies larger than memory. The words are kept in a B+tree of
+// fixed size pages rather than a radix tree, since wide page sized nodes keep a lookup to a few reads, and only the
+// most recently used pages are kept in memory. A word too long for a page is stored under its first bytes followed
+// by a hash of the whole word, Walk reports it under that key.
+//
+// Pages that belong to the last commit are never overwritten, a changed page is written somewhere else and its parents
+// with it. Commit makes every change since the previous commit durable at once, and a crash or a Close without Commit
+// loses those changes but never corrupts the file.
+type DiskTree struct {
+	mu       sync.Mutex
+	file     *os.File
+	pageSize int
+	meta     meta
+
+	capacity int
+	cache    map[uint64]*list.Element
+	lru      *list.List
+
+	// fresh are the pages allocated since the last commit, they can be changed in place
+	fresh map[uint64]bool
+	// pending are the pages replaced since the last commit, they become free once the commit is durable
+	pending []uint64
+	free    []uint64
+
+	// err is the first read error of a method that cannot return one, it is returned by Commit
+	err error
+}
+
+// meta is the root of the tree and the statistics of a commit
+type meta struct {
+	txid   uint64
+	root   uint64
+	pages  uint64
+	unique int
+	totals []int
+	types  []int
+	data   []byte
+}
+
+// page is a node of the B+tree, a leaf holds words and their counts, a branch holds separator keys with children
+// to the left and right of each
+type page struct {
+	id       uint64
+	leaf     bool
+	keys     []string
+	counts   [][]int
+	children []uint64
+	dirty    bool
+}
+
+// Open opens the disk tree stored at path, creating it when the file does not exist, and keeps at most cachePages of
+// its pages in memory
+func Open(path string, numCategories int, cachePages int) (*DiskTree, error) {
+	return openDisk(path, numCategories, cachePages, DefaultPageSize)
+}
+
+func openDisk(path string, numCategories int, cachePages int, pageSize int) (*DiskTree, error) {
+	// every count of a word and the key of a long word have to fit in a quarter of a page, and the totals in a meta
+	// page
+	if numCategories <= 0 || metaHeader+16*numCategories+8 > pageSize ||
+		10*numCategories+uvarintLen(pageSize)+longWordSuffix > maxEntry(pageSize) {
+		return nil, ErrInvalidCategoryCount
+	}
+
+	if cachePages < minCachePages {
+		cachePages = minCachePages
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &DiskTree{
+		file:     file,
+		pageSize: pageSize,
+		capacity: cachePages,
+		cache:    make(map[uint64]*list.Element),
+		lru:      list.New(),
+		fresh:    make(map[uint64]bool),
+	}
+
+	info, err := file.Stat()
+	if err == nil && info.Size() == 0 {
+		err = t.create(numCategories)
+	} else if err == nil {
+		err = t.load(numCategories)
+	}
+
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// create writes an empty tree to a new file
+func (t *DiskTree) create(numCategories int) error {
+	t.meta = meta{
+		root:   metaPages,
+		pages:  metaPages + 1,
+		totals: make([]int, numCategories, numCategories),
+		types:  make([]int, numCategories, numCategories),
+	}
+
+	if err := t.writePage(&page{id: metaPages, leaf: true}); err != nil {
+		return err
+	}
+
+	if err := t.writeMeta(); err != nil {
+		return err
+	}
+	return t.file.Sync()
+}
+
+// load reads the newest valid commit and finds the pages it does not use
+func (t *DiskTree) load(numCategories int) error {
+	var best *meta
+	for slot := uint64(0); slot < metaPages; slot++ {
+		m, err := t.readMeta(slot, numCategories)
+		if err == ErrInvalidCategoryCount {
+			return err
+		}
+
+		if err == nil && (best == nil || m.txid > best.txid) {
+			best = m
+		}
+	}
+
+	if best == nil {
+		return ErrCorrupt
+	}
+	t.meta = *best
+
+	// every page that the tree does not reach is free, the leaves are all on the same level, so only the branches
+	// and a single leaf have to be read to find them
+	used := make(map[uint64]bool)
+	level := []uint64{t.meta.root}
+	for len(level) > 0 {
+		first, err := t.readPage(level[0])
+		if err != nil {
+			return err
+		}
+
+		var next []uint64
+		for _, id := range level {
+			if id < metaPages || id >= t.meta.pages || used[id] {
+				return ErrCorrupt
+			}
+			used[id] = true
+
+			if first.leaf {
+				continue
+			}
+
+			p, err := t.readPage(id)
+			if err != nil {
+				return err
+			}
+			if p.leaf {
+				return ErrCorrupt
+			}
+			next = append(next, p.children...)
+		}
+		level = next
+	}
+
+	for id := uint64(metaPages); id < t.meta.pages; id++ {
+		if !used[id] {
+			t.free = append(t.free, id)
+		}
+	}
+	return nil
+}
+
+// Insert increments the count of a word in a category
+func (t *DiskTree) Insert(needle string, category int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return ErrClosed
+	}
+
+	if category < 0 || category >= len(t.meta.totals) {
+		return ErrOutOfBoundsCategory
+	}
+
+	needle = t.key(needle)
+	path, idx, err := t.descend(needle)
+	if err != nil {
+		return err
+	}
+	t.touch(path, idx)
+
+	leaf := path[len(path)-1]
+	i := sort.SearchStrings(leaf.keys, needle)
+	if i == len(leaf.keys) || leaf.keys[i] != needle {
+		leaf.keys = append(leaf.keys, "")
+		copy(leaf.keys[i+1:], leaf.keys[i:])
+		leaf.keys[i] = needle
+
+		leaf.counts = append(leaf.counts, nil)
+		copy(leaf.counts[i+1:], leaf.counts[i:])
+		leaf.counts[i] = make([]int, len(t.meta.totals), len(t.meta.totals))
+
+		t.meta.unique++
+	}
+
+	leaf.counts[i][category]++
+	if leaf.counts[i][category] == 1 {
+		t.meta.types[category]++
+	}
+	t.meta.totals[category]++
+
+	return t.split(path, idx)
+}
+
+// Remove decrements the count of a word in a category, the word is deleted once it has no counts
+func (t *DiskTree) Remove(needle string, category int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return ErrClosed
+	}
+
+	if category < 0 || category >= len(t.meta.totals) {
+		return ErrOutOfBoundsCategory
+	}
+
+	needle = t.key(needle)
+	path, idx, err := t.descend(needle)
+	if err != nil {
+		return err
+	}
+
+	leaf := path[len(path)-1]
+	i := sort.SearchStrings(leaf.keys, needle)
+	if i == len(leaf.keys) || leaf.keys[i] != needle || leaf.counts[i][category] == 0 {
+		return ErrNotFound
+	}
+	t.touch(path, idx)
+
+	leaf.counts[i][category]--
+	t.meta.totals[category]--
+	if leaf.counts[i][category] == 0 {
+		t.meta.types[category]--
+	}
+
+	empty := true
+	for _, count := range leaf.counts[i] {
+		if count != 0 {
+			empty = false
+		}
+	}
+
+	// pages are never merged, an emptied leaf stays in place until words are inserted into it again
+	if empty {
+		leaf.keys = append(leaf.keys[:i], leaf.keys[i+1:]...)
+		leaf.counts = append(leaf.counts[:i], leaf.counts[i+1:]...)
+		t.meta.unique--
+	}
+
+	return t.putAll(path)
+}
+
+// Find gets the category values associated with a given string, the counts must not be modified
+func (t *DiskTree) Find(needle string) ([]int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return nil, false
+	}
+
+	needle = t.key(needle)
+	path, _, err := t.descend(needle)
+	if err != nil {
+		t.fail(err)
+		return nil, false
+	}
+
+	leaf := path[len(path)-1]
+	i := sort.SearchStrings(leaf.keys, needle)
+	if i == len(leaf.keys) || leaf.keys[i] != needle {
+		return nil, false
+	}
+	return leaf.counts[i], true
+}
+
+// GetTotals fetches the totals associated with each category
+func (t *DiskTree) GetTotals() []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.meta.totals
+}
+
+// CategoryCount returns the number of categories we are tracking in this tree
+func (t *DiskTree) CategoryCount() int {
+	return len(t.meta.totals)
+}
+
+// UniqueWords returns the number of words in the tree
+func (t *DiskTree) UniqueWords() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.meta.unique
+}
+
+// CategoryUniqueWords returns the number of distinct words seen in each category
+func (t *DiskTree) CategoryUniqueWords() []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.meta.types
+}
+
+// Walk visits every word in the tree in lexical order. The tree is only locked while a leaf is read, fn is called
+// without holding the lock.
+func (t *DiskTree) Walk(fn func(word string, counts []int) bool) {
+	from, inclusive := "", true
+	for {
+		t.mu.Lock()
+		keys, counts, next, more, err := t.leafFrom(from, inclusive)
+		t.mu.Unlock()
+		if err != nil {
+			t.mu.Lock()
+			t.fail(err)
+			t.mu.Unlock()
+			return
+		}
+
+		for i := range keys {
+			if !fn(keys[i], counts[i]) {
+				return
+			}
+		}
+
+		if !more {
+			return
+		}
+		from, inclusive = next, true
+	}
+}
+
+// leafFrom copies the words of the leaf that from leads to that come after it. When there are more leaves to the
+// right, more is set and next is the smallest key they can hold.
+func (t *DiskTree) leafFrom(from string, inclusive bool) ([]string, [][]int, string, bool, error) {
+	if t.file == nil {
+		return nil, nil, "", false, ErrClosed
+	}
+
+	path, idx, err := t.descend(from)
+	if err != nil {
+		return nil, nil, "", false, err
+	}
+
+	leaf := path[len(path)-1]
+	start := sort.Search(len(leaf.keys), func(i int) bool {
+		return leaf.keys[i] > from || (inclusive && leaf.keys[i] == from)
+	})
+
+	keys := append([]string(nil), leaf.keys[start:]...)
+	counts := make([][]int, len(keys))
+	for i := range counts {
+		counts[i] = append([]int(nil), leaf.counts[start+i]...)
+	}
+
+	// the next leaf is under the closest ancestor that has a child to the right of the path
+	for d := len(idx) - 1; d >= 0; d-- {
+		if idx[d] < len(path[d].keys) {
+			return keys, counts, path[d].keys[idx[d]], true, nil
+		}
+	}
+	return keys, counts, "", false, nil
+}
+
+// key is the key a word is stored under, a word whose entry would not fit in a page is cut short and ends in a hash
+// of the whole word instead
+func (t *DiskTree) key(needle string) string {
+	max := maxEntry(t.pageSize) - 10*len(t.meta.totals) - uvarintLen(t.pageSize)
+	if len(needle) <= max {
+		return needle
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(needle))
+	return fmt.Sprintf("%s\x00%016x", needle[:max-longWordSuffix], h.Sum64())
+}
+
+// Metadata is the opaque data stored with the tree, or nil when none was set
+func (t *DiskTree) Metadata() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.meta.data
+}
+
+// SetMetadata replaces the opaque data stored with the tree, it is made durable by the next commit
+func (t *DiskTree) SetMetadata(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return ErrClosed
+	}
+
+	if metaHeader+16*len(t.meta.totals)+4+len(data)+4 > t.pageSize {
+		return ErrMetadataTooLarge
+	}
+	t.meta.data = append([]byte(nil), data...)
+	return nil
+}
+
+// Commit makes every change since the last commit durable
+func (t *DiskTree) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return ErrClosed
+	}
+
+	if t.err != nil {
+		return t.err
+	}
+
+	for _, e := range t.cache {
+		p := e.Value.(*page)
+		if p.dirty {
+			if err := t.writePage(p); err != nil {
+				return err
+			}
+			p.dirty = false
+		}
+	}
+
+	// the pages have to be durable before the meta page that points at them is written
+	if err := t.file.Sync(); err != nil {
+		return err
+	}
+
+	t.meta.txid++
+	if err := t.writeMeta(); err != nil {
+		t.meta.txid--
+		return err
+	}
+
+	if err := t.file.Sync(); err != nil {
+		return err
+	}
+
+	t.free = append(t.free, t.pending...)
+	t.pending = nil
+	t.fresh = make(map[uint64]bool)
+	return nil
+}
+
+// Close closes the file, changes that were not committed are lost
+func (t *DiskTree) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return ErrClosed
+	}
+
+	err := t.file.Close()
+	t.file = nil
+	return err
+}
+
+// fail remembers the first error of a method that cannot return it
+func (t *DiskTree) fail(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+// descend finds the leaf a word belongs in, it returns the pages from the root to the leaf and which child was
+// taken in each branch
+func (t *DiskTree) descend(needle string) ([]*page, []int, error) {
+	p, err := t.get(t.meta.root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path := []*page{p}
+	var idx []int
+	for !p.leaf {
+		// the child to the right of a separator holds the words greater than or equal to it
+		i := sort.Search(len(p.keys), func(i int) bool { return p.keys[i] > needle })
+		if p, err = t.get(p.children[i]); err != nil {
+			return nil, nil, err
+		}
+
+		path = append(path, p)
+		idx = append(idx, i)
+	}
+	return path, idx, nil
+}
+
+// touch makes the pages of a path writable, the ones that are part of the last commit are moved to new pages and
+// their parents are pointed at the new location
+func (t *DiskTree) touch(path []*page, idx []int) {
+	for d, p := range path {
+		if !t.fresh[p.id] {
+			t.drop(p.id)
+			t.pending = append(t.pending, p.id)
+			p.id = t.alloc()
+
+			if d == 0 {
+				t.meta.root = p.id
+			} else {
+				path[d-1].children[idx[d-1]] = p.id
+			}
+		}
+		p.dirty = true
+	}
+}
+
+// alloc returns a free page, or grows the file by one page
+func (t *DiskTree) alloc() uint64 {
+	var id uint64
+	if n := len(t.free); n > 0 {
+		id = t.free[n-1]
+		t.free = t.free[:n-1]
+	} else {
+		id = t.meta.pages
+		t.meta.pages++
+	}
+
+	t.fresh[id] = true
+	return id
+}
+
+// split splits the pages of a touched path that no longer fit, from the leaf up, and puts them in the cache
+func (t *DiskTree) split(path []*page, idx []int) error {
+	for d := len(path) - 1; d >= 0; d-- {
+		p := path[d]
+		if p.size() <= t.pageSize {
+			break
+		}
+
+		right, separator := p.splitHalf()
+		right.id = t.alloc()
+		if err := t.put(right); err != nil {
+			return err
+		}
+
+		if d == 0 {
+			root := &page{id: t.alloc(), keys: []string{separator}, children: []uint64{p.id, right.id}, dirty: true}
+			t.meta.root = root.id
+			path = append([]*page{root}, path...)
+			break
+		}
+
+		parent, i := path[d-1], idx[d-1]
+		parent.keys = append(parent.keys, "")
+		copy(parent.keys[i+1:], parent.keys[i:])
+		parent.keys[i] = separator
+
+		parent.children = append(parent.children, 0)
+		copy(parent.children[i+2:], parent.children[i+1:])
+		parent.children[i+1] = right.id
+	}
+
+	return t.putAll(path)
+}
+
+// splitHalf moves the upper half of a page's keys to a new page, and returns it with the key that separates them
+func (p *page) splitHalf() (*page, string) {
+	half := p.size() / 2
+	m, size := 0, pageHeader
+	for m < len(p.keys)-1 && size < half {
+		size += p.entrySize(m)
+		m++
+	}
+	if m == 0 {
+		m = 1
+	}
+
+	right := &page{leaf: p.leaf, dirty: true}
+	if p.leaf {
+		right.keys = append([]string(nil), p.keys[m:]...)
+		right.counts = append([][]int(nil), p.counts[m:]...)
+		p.keys, p.counts = p.keys[:m:m], p.counts[:m:m]
+		return right, right.keys[0]
+	}
+
+	// a branch's middle key moves up to the parent, it is not kept in either half
+	separator := p.keys[m]
+	right.keys = append([]string(nil), p.keys[m+1:]...)
+	right.children = append([]uint64(nil), p.children[m+1:]...)
+	p.keys, p.children = p.keys[:m:m], p.children[:m+1:m+1]
+	return right, separator
+}
+
+// get returns a page from the cache, reading it from the file if it is not there
+func (t *DiskTree) get(id uint64) (*page, error) {
+	if e, ok := t.cache[id]; ok {
+		t.lru.MoveToFront(e)
+		return e.Value.(*page), nil
+	}
+
+	p, err := t.readPage(id)
+	if err != nil {
+		return nil, err
+	}
+	return p, t.put(p)
+}
+
+// put adds a page to the cache, writing out the least recently used dirty pages that no longer fit
+func (t *DiskTree) put(p *page) error {
+	if e, ok := t.cache[p.id]; ok {
+		e.Value = p
+		t.lru.MoveToFront(e)
+	} else {
+		t.cache[p.id] = t.lru.PushFront(p)
+	}
+
+	for t.lru.Len() > t.capacity {
+		e := t.lru.Back()
+		evicted := e.Value.(*page)
+		if evicted.dirty {
+			// dirty pages are always fresh, so writing them never touches the last commit
+			if err := t.writePage(evicted); err != nil {
+				return err
+			}
+			evicted.dirty = false
+		}
+
+		t.lru.Remove(e)
+		delete(t.cache, evicted.id)
+	}
+	return nil
+}
+
+// putAll puts every page of a path in the cache
+func (t *DiskTree) putAll(path []*page) error {
+	for _, p := range path {
+		if err := t.put(p); err != nil {
+			t.fail(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// drop removes a page from the cache without writing it
+func (t *DiskTree) drop(id uint64) {
+	if e, ok := t.cache[id]; ok {
+		t.lru.Remove(e)
+		delete(t.cache, id)
+	}
+}
+
+func (t *DiskTree) readPage(id uint64) (*page, error) {
+	buf := make([]byte, t.pageSize)
+	if _, err := t.file.ReadAt(buf, int64(id)*int64(t.pageSize)); err != nil {
+		return nil, err
+	}
+
+	p, err := decodePage(buf, len(t.meta.totals))
+	if err != nil {
+		return nil, err
+	}
+	p.id = id
+	return p, nil
+}
+
+func (t *DiskTree) writePage(p *page) error {
+	buf := make([]byte, t.pageSize)
+	p.encode(buf)
+	_, err := t.file.WriteAt(buf, int64(p.id)*int64(t.pageSize))
+	return err
+}
+
+// writeMeta writes the meta page into the slot of its transaction, leaving the previous commit in the other slot
+func (t *DiskTree) writeMeta() error {
+	buf := make([]byte, t.pageSize)
+	copy(buf, diskMagic)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(t.pageSize))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(len(t.meta.totals)))
+	binary.LittleEndian.PutUint64(buf[16:], t.meta.txid)
+	binary.LittleEndian.PutUint64(buf[24:], t.meta.root)
+	binary.LittleEndian.PutUint64(buf[32:], t.meta.pages)
+	binary.LittleEndian.PutUint64(buf[40:], uint64(t.meta.unique))
+
+	pos := metaHeader
+	for _, counts := range [][]int{t.meta.totals, t.meta.types} {
+		for _, count := range counts {
+			binary.LittleEndian.PutUint64(buf[pos:], uint64(count))
+			pos += 8
+		}
+	}
+	binary.LittleEndian.PutUint32(buf[pos:], uint32(len(t.meta.data)))
+	pos += 4
+	pos += copy(buf[pos:], t.meta.data)
+	binary.LittleEndian.PutUint32(buf[pos:], crc32.ChecksumIEEE(buf[:pos]))
+
+	_, err := t.file.WriteAt(buf, int64(t.meta.txid%metaPages)*int64(t.pageSize))
+	return err
+}
+
+// readMeta reads the commit in a meta slot, a torn or missing one is reported as corrupt
+func (t *DiskTree) readMeta(slot uint64, numCategories int) (*meta, error) {
+	buf := make([]byte, t.pageSize)
+	if _, err := t.file.ReadAt(buf, int64(slot)*int64(t.pageSize)); err != nil {
+		return nil, ErrCorrupt
+	}
+
+	if string(buf[:8]) != diskMagic || int(binary.LittleEndian.Uint32(buf[8:])) != t.pageSize {
+		return nil, ErrCorrupt
+	}
+
+	n := int(binary.LittleEndian.Uint32(buf[12:]))
+	pos := metaHeader + 16*n
+	if n <= 0 || pos+8 > len(buf) {
+		return nil, ErrCorrupt
+	}
+
+	size := int(binary.LittleEndian.Uint32(buf[pos:]))
+	pos += 4
+	if size > len(buf)-pos-4 || binary.LittleEndian.Uint32(buf[pos+size:]) != crc32.ChecksumIEEE(buf[:pos+size]) {
+		return nil, ErrCorrupt
+	}
+
+	if n != numCategories {
+		return nil, ErrInvalidCategoryCount
+	}
+
+	m := &meta{
+		txid:   binary.LittleEndian.Uint64(buf[16:]),
+		root:   binary.LittleEndian.Uint64(buf[24:]),
+		pages:  binary.LittleEndian.Uint64(buf[32:]),
+		unique: int(binary.LittleEndian.Uint64(buf[40:])),
+		totals: make([]int, n, n),
+		types:  make([]int, n, n),
+	}
+	if size > 0 {
+		m.data = append([]byte(nil), buf[pos:pos+size]...)
+	}
+
+	pos = metaHeader
+	for _, counts := range [][]int{m.totals, m.types} {
+		for i := range counts {
+			counts[i] = int(binary.LittleEndian.Uint64(buf[pos:]))
+			pos += 8
+		}
+	}
+	return m, nil
+}
+
+// size is the number of bytes the page takes when encoded
+func (p *page) size() int {
+	size := pageHeader
+	if !p.leaf {
+		size += 8
+	}
+	for i := range p.keys {
+		size += p.entrySize(i)
+	}
+	return size
+}
+
+// entrySize is the number of bytes the i-th key takes when encoded, along with its counts or right child
+func (p *page) entrySize(i int) int {
+	size := uvarintLen(len(p.keys[i])) + len(p.keys[i])
+	if !p.leaf {
+		return size + 8
+	}
+
+	for _, count := range p.counts[i] {
+		size += uvarintLen(count)
+	}
+	return size
+}
+
+// encode writes the page into buf. A leaf is each key followed by its counts, a branch is its leftmost child
+// followed by each key and the child to its right.
+func (p *page) encode(buf []byte) {
+	buf[0] = branchPage
+	if p.leaf {
+		buf[0] = leafPage
+	}
+	binary.LittleEndian.PutUint16(buf[1:], uint16(len(p.keys)))
+
+	pos := pageHeader
+	if !p.leaf {
+		binary.LittleEndian.PutUint64(buf[pos:], p.children[0])
+		pos += 8
+	}
+
+	for i, key := range p.keys {
+		pos += binary.PutUvarint(buf[pos:], uint64(len(key)))
+		pos += copy(buf[pos:], key)
+
+		if !p.leaf {
+			binary.LittleEndian.PutUint64(buf[pos:], p.children[i+1])
+			pos += 8
+			continue
+		}
+
+		for _, count := range p.counts[i] {
+			pos += binary.PutUvarint(buf[pos:], uint64(count))
+		}
+	}
+}
+
+// decodePage reads a page written by encode
+func decodePage(buf []byte, numCategories int) (*page, error) {
+	kind := buf[0]
+	if kind != leafPage && kind != branchPage {
+		return nil, ErrCorrupt
+	}
+
+	n := int(binary.LittleEndian.Uint16(buf[1:]))
+	p := &page{leaf: kind == leafPage, keys: make([]string, n)}
+	pos := pageHeader
+
+	// uvarint reads the next number, or reports a page that ends in the middle of one
+	uvarint := func() (int, bool) {
+		v, w := binary.Uvarint(buf[pos:])
+		if w <= 0 {
+			return 0, false
+		}
+		pos += w
+		return int(v), true
+	}
+
+	if p.leaf {
+		p.counts = make([][]int, n)
+	} else {
+		if pos+8 > len(buf) {
+			return nil, ErrCorrupt
+		}
+		p.children = append(p.children, binary.LittleEndian.Uint64(buf[pos:]))
+		pos += 8
+	}
+
+	for i := 0; i < n; i++ {
+		length, ok := uvarint()
+		if !ok || length < 0 || pos+length > len(buf) {
+			return nil, ErrCorrupt
+		}
+		p.keys[i] = string(buf[pos : pos+length])
+		pos += length
+
+		if !p.leaf {
+			if pos+8 > len(buf) {
+				return nil, ErrCorrupt
+			}
+			p.children = append(p.children, binary.LittleEndian.Uint64(buf[pos:]))
+			pos += 8
+			continue
+		}
+
+		p.counts[i] = make([]int, numCategories, numCategories)
+		for j := range p.counts[i] {
+			if p.counts[i][j], ok = uvarint(); !ok {
+				return nil, ErrCorrupt
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// maxEntry is the largest a leaf entry can be, at most a quarter of a page so that a split page always fits
+func maxEntry(pageSize int) int {
+	return (pageSize - pageHeader - 8) / 4
+}
+
+// uvarintLen is the number of bytes binary.PutUvarint takes for n
+func uvarintLen(n int) int {
+	size := 1
+	for v := uint64(n); v >= 0x80; v >>= 7 {
+		size++
+	}
+	return size
+}
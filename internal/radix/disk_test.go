@@ -0,0 +1,220 @@
+package radix
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// openTestDisk opens a disk tree with small pages and a small cache, so that tests split pages and evict them
+func openTestDisk(t *testing.T, path string, categories int) *DiskTree {
+	tree, err := openDisk(path, categories, minCachePages, 256)
+	assert.NoError(t, err)
+	return tree
+}
+
+func TestDiskTree(t *testing.T) {
+	runTreeTests(t, func(t *testing.T, categories int) Tree {
+		tree := openTestDisk(t, filepath.Join(t.TempDir(), "tree.db"), categories)
+		t.Cleanup(func() { _ = tree.Close() })
+		return tree
+	})
+}
+
+func TestDiskTreeCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openTestDisk(t, path, 2)
+
+	for i := 0; i < 2000; i++ {
+		assert.NoError(t, tree.Insert(fmt.Sprintf("word%d", i), i%2))
+	}
+	assert.NoError(t, tree.Commit())
+
+	// changes after the last commit are lost when the tree is closed
+	assert.NoError(t, tree.Insert("uncommitted", 0))
+	assert.NoError(t, tree.Remove("word0", 0))
+	assert.NoError(t, tree.Close())
+	assert.Equal(t, ErrClosed, tree.Insert("closed", 0))
+
+	tree = openTestDisk(t, path, 2)
+	defer func() { _ = tree.Close() }()
+	assert.Equal(t, 2000, tree.UniqueWords())
+	assert.Equal(t, []int{1000, 1000}, tree.GetTotals())
+	assert.Equal(t, []int{1000, 1000}, tree.CategoryUniqueWords())
+
+	_, found := tree.Find("uncommitted")
+	assert.False(t, found)
+	for i := 0; i < 2000; i++ {
+		counts, found := tree.Find(fmt.Sprintf("word%d", i))
+		assert.True(t, found)
+		assert.Equal(t, 1, counts[i%2])
+	}
+
+	var words int
+	previous := ""
+	tree.Walk(func(word string, counts []int) bool {
+		assert.True(t, word > previous)
+		previous = word
+		words++
+		return true
+	})
+	assert.Equal(t, 2000, words)
+}
+
+func TestDiskTreeReusesPages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openTestDisk(t, path, 1)
+	defer func() { _ = tree.Close() }()
+
+	for i := 0; i < 500; i++ {
+		assert.NoError(t, tree.Insert(fmt.Sprintf("word%d", i), 0))
+	}
+	assert.NoError(t, tree.Commit())
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	// rewriting the same pages over and over only needs the pages the previous commit freed
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, tree.Insert("word1", 0))
+		assert.NoError(t, tree.Commit())
+	}
+
+	grown, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.True(t, grown.Size() <= info.Size()+int64(8*256), "%d > %d", grown.Size(), info.Size())
+
+	counts, found := tree.Find("word1")
+	assert.True(t, found)
+	assert.Equal(t, []int{101}, counts)
+}
+
+func TestDiskTreeErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tree.db")
+
+	_, err := openDisk(path, 0, minCachePages, 256)
+	assert.Equal(t, ErrInvalidCategoryCount, err)
+	_, err = openDisk(path, 100, minCachePages, 256)
+	assert.Equal(t, ErrInvalidCategoryCount, err)
+
+	tree := openTestDisk(t, path, 2)
+	assert.Equal(t, ErrMetadataTooLarge, tree.SetMetadata(make([]byte, 256)))
+	assert.NoError(t, tree.Close())
+	assert.Equal(t, ErrClosed, tree.SetMetadata(nil))
+
+	_, err = openDisk(path, 3, minCachePages, 256)
+	assert.Equal(t, ErrInvalidCategoryCount, err)
+
+	garbage := filepath.Join(dir, "garbage.db")
+	assert.NoError(t, os.WriteFile(garbage, make([]byte, 1024), 0644))
+	_, err = openDisk(garbage, 2, minCachePages, 256)
+	assert.Equal(t, ErrCorrupt, err)
+}
+
+func TestDiskTreeLongWords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openTestDisk(t, path, 2)
+
+	long := strings.Repeat("a", 100)
+	longer := long + "b"
+	assert.NoError(t, tree.Insert(long, 0))
+	assert.NoError(t, tree.Insert(longer, 1))
+	assert.NoError(t, tree.Insert(longer, 1))
+	assert.Equal(t, 2, tree.UniqueWords())
+
+	counts, found := tree.Find(long)
+	assert.True(t, found)
+	assert.Equal(t, []int{1, 0}, counts)
+	counts, found = tree.Find(longer)
+	assert.True(t, found)
+	assert.Equal(t, []int{0, 2}, counts)
+	_, found = tree.Find(long + "c")
+	assert.False(t, found)
+
+	assert.NoError(t, tree.Remove(long, 0))
+	_, found = tree.Find(long)
+	assert.False(t, found)
+	assert.NoError(t, tree.Close())
+}
+
+func TestDiskTreeMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openTestDisk(t, path, 2)
+	assert.Nil(t, tree.Metadata())
+
+	assert.NoError(t, tree.SetMetadata([]byte("settings")))
+	assert.Equal(t, []byte("settings"), tree.Metadata())
+	assert.NoError(t, tree.Commit())
+	assert.NoError(t, tree.SetMetadata([]byte("uncommitted")))
+	assert.NoError(t, tree.Close())
+
+	tree = openTestDisk(t, path, 2)
+	assert.Equal(t, []byte("settings"), tree.Metadata())
+	assert.NoError(t, tree.Close())
+}
+
+func BenchmarkDiskInsert(b *testing.B) {
+	tree, err := Open(filepath.Join(b.TempDir(), "tree.db"), 1, 1024)
+	assert.NoError(b, err)
+	defer func() { _ = tree.Close() }()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = tree.Insert(randString(), 0)
+	}
+}
+
+func TestDiskTreeMatchesMemory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	disk := openTestDisk(t, path, 3)
+	memory, err := New(3)
+	assert.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(1))
+	var words []string
+	for i := 0; i < 20000; i++ {
+		category := rng.Intn(3)
+		switch {
+		case len(words) > 0 && rng.Intn(4) == 0:
+			word := words[rng.Intn(len(words))]
+			assert.Equal(t, memory.(Remover).Remove(word, category), disk.Remove(word, category))
+		case rng.Intn(50) == 0:
+			assert.NoError(t, disk.Commit())
+		default:
+			word := randString()
+			words = append(words, word)
+			assert.NoError(t, memory.Insert(word, category))
+			assert.NoError(t, disk.Insert(word, category))
+		}
+	}
+	assert.NoError(t, disk.Commit())
+	assert.NoError(t, disk.Close())
+
+	disk = openTestDisk(t, path, 3)
+	defer func() { _ = disk.Close() }()
+
+	assert.Equal(t, memory.UniqueWords(), disk.UniqueWords())
+	assert.Equal(t, memory.GetTotals(), disk.GetTotals())
+	assert.Equal(t, memory.(TypeCounter).CategoryUniqueWords(), disk.CategoryUniqueWords())
+
+	type entry struct {
+		word   string
+		counts []int
+	}
+	var want, got []entry
+	memory.(Walker).Walk(func(word string, counts []int) bool {
+		want = append(want, entry{word, append([]int(nil), counts...)})
+		return true
+	})
+	disk.Walk(func(word string, counts []int) bool {
+		got = append(got, entry{word, counts})
+		return true
+	})
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,127 @@
+package radix
+
+import (
+	"sort"
+)
+
+// FuzzyFinder is implemented by trees that can find the words near a needle that is not in them
+type FuzzyFinder interface {
+	// FindFuzzy returns the words within maxEdits insertions, deletions or substitutions of a character of the needle,
+	// nearest first and in lexical order among equally near words
+	FindFuzzy(needle string, maxEdits int) []FuzzyMatch
+}
+
+// FuzzyMatch is a word found near a needle
+type FuzzyMatch struct {
+	Word     string
+	Counts   []int
+	Distance int
+}
+
+// fuzzySearch is the state of one search, the Levenshtein rows are kept one per depth of the word and reused by every
+// word of that length, so a search allocates a row per character of its longest word rather than per node
+type fuzzySearch struct {
+	needle   []rune
+	maxEdits int
+	rows     [][]int
+	matches  []FuzzyMatch
+}
+
+// row returns the row for a word of depth characters
+func (s *fuzzySearch) row(depth int) []int {
+	for len(s.rows) <= depth {
+		s.rows = append(s.rows, make([]int, len(s.needle)+1))
+	}
+	return s.rows[depth]
+}
+
+// FindFuzzy walks the tree keeping one row of the Levenshtein table per node, so words sharing a prefix share its
+// rows, and leaves a subtree as soon as every entry of the row is over maxEdits, since going deeper only adds edits
+func (r *root) FindFuzzy(needle string, maxEdits int) []FuzzyMatch {
+	if maxEdits < 0 {
+		return nil
+	}
+
+	s := &fuzzySearch{needle: []rune(needle), maxEdits: maxEdits}
+	row := s.row(0)
+	for i := range row {
+		row[i] = i
+	}
+	// the words are built in one buffer too, a word only extends the one leading to its node
+	r.Root.fuzzy(make([]rune, 0, 64), s)
+
+	matches := s.matches
+	sort.Slice(matches, func(i int, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Word < matches[j].Word
+	})
+	return matches
+}
+
+// fuzzy collects the matches below a node, the row of the word leading to it is the search's row at its depth
+func (n *node) fuzzy(word []rune, s *fuzzySearch) {
+	row := s.rows[len(word)]
+	if distance := row[len(s.needle)]; n.IsLeaf && distance <= s.maxEdits {
+		s.matches = append(s.matches, FuzzyMatch{Word: string(word), Counts: n.Values, Distance: distance})
+	}
+
+	for _, c := range n.Children {
+		childWord, childRow := word, row
+		pruned := false
+		for _, r := range c.Prefix {
+			childWord = append(childWord, r)
+			childRow = nextRow(s.row(len(childWord)), childRow, s.needle, r)
+			if minimum(childRow) > s.maxEdits {
+				pruned = true
+				break
+			}
+		}
+
+		if !pruned {
+			c.Node.fuzzy(childWord, s)
+		}
+	}
+}
+
+// nextRow writes the Levenshtein row after one more character of the word into row and returns it
+func nextRow(row []int, prev []int, needle []rune, r rune) []int {
+	row[0] = prev[0] + 1
+	for j := 1; j < len(row); j++ {
+		cost := 1
+		if needle[j-1] == r {
+			cost = 0
+		}
+		// a substitution or match, unless deleting or inserting a character is cheaper
+		row[j] = prev[j-1] + cost
+		if deletion := prev[j] + 1; deletion < row[j] {
+			row[j] = deletion
+		}
+		if insertion := row[j-1] + 1; insertion < row[j] {
+			row[j] = insertion
+		}
+	}
+	return row
+}
+
+// minimum returns the smallest entry of a row
+func minimum(row []int) int {
+	m := row[0]
+	for _, v := range row[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// FindFuzzy finds the words near a needle in the current version
+func (t *persistent) FindFuzzy(needle string, maxEdits int) []FuzzyMatch {
+	return t.load().FindFuzzy(needle, maxEdits)
+}
+
+// FindFuzzy finds the words near a needle in the live epochs
+func (t *window) FindFuzzy(needle string, maxEdits int) []FuzzyMatch {
+	return t.Aggregate.(FuzzyFinder).FindFuzzy(needle, maxEdits)
+}
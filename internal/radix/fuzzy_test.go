@@ -0,0 +1,135 @@
+package radix
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindFuzzy(t *testing.T) {
+	tree, err := New(2)
+	assert.NoError(t, err)
+	for _, word := range []string{"viagra", "viagras", "niagara", "vintage", "agra", "víagra"} {
+		assert.NoError(t, tree.Insert(word, 1))
+	}
+	assert.NoError(t, tree.Insert("viagra", 0))
+	finder := tree.(FuzzyFinder)
+
+	matches := finder.FindFuzzy("v1agra", 1)
+	assert.Equal(t, []FuzzyMatch{
+		{Word: "viagra", Counts: []int{1, 1}, Distance: 1},
+		{Word: "víagra", Counts: []int{0, 1}, Distance: 1},
+	}, matches)
+
+	// distances count characters, not bytes
+	matches = finder.FindFuzzy("viagrra", 2)
+	var words []string
+	for _, match := range matches {
+		words = append(words, match.Word)
+	}
+	assert.Equal(t, []string{"viagra", "niagara", "viagras", "víagra"}, words)
+	for i, distance := range []int{1, 2, 2, 2} {
+		assert.Equal(t, distance, matches[i].Distance)
+	}
+
+	// an exact match is at distance 0, and nothing is found beyond the budget
+	matches = finder.FindFuzzy("agra", 0)
+	assert.Equal(t, []FuzzyMatch{{Word: "agra", Counts: []int{0, 1}, Distance: 0}}, matches)
+	assert.Empty(t, finder.FindFuzzy("xyzzy", 2))
+	assert.Empty(t, finder.FindFuzzy("viagra", -1))
+
+	// removed words are not found
+	assert.NoError(t, tree.(Remover).Remove("agra", 1))
+	assert.Empty(t, finder.FindFuzzy("agra", 0))
+}
+
+// levenshtein is the textbook edit distance between two words
+func levenshtein(a string, b string) int {
+	x, y := []rune(a), []rune(b)
+	prev := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range x {
+		row := make([]int, len(y)+1)
+		row[0] = i + 1
+		for j := range y {
+			cost := 1
+			if x[i] == y[j] {
+				cost = 0
+			}
+			row[j+1] = prev[j] + cost
+			if prev[j+1]+1 < row[j+1] {
+				row[j+1] = prev[j+1] + 1
+			}
+			if row[j]+1 < row[j+1] {
+				row[j+1] = row[j] + 1
+			}
+		}
+		prev = row
+	}
+	return prev[len(y)]
+}
+
+func TestFindFuzzyMatchesBruteForce(t *testing.T) {
+	rand.Seed(1)
+	trees := map[string]Tree{}
+	trees["Memory"], _ = New(2)
+	trees["Persistent"], _ = NewPersistent(2)
+	trees["Window"], _ = NewWindow(2, 3, time.Hour, time.Now())
+
+	var words []string
+	for i := 0; i < 2000; i++ {
+		word := truncate(randString(), 6)
+		words = append(words, word)
+		for _, tree := range trees {
+			assert.NoError(t, tree.Insert(word, i%2))
+		}
+	}
+
+	for name, tree := range trees {
+		finder := tree.(FuzzyFinder)
+		for i := 0; i < 50; i++ {
+			needle := truncate(randString(), 6)
+			for maxEdits := 0; maxEdits <= 2; maxEdits++ {
+				var want []FuzzyMatch
+				tree.(Walker).Walk(func(word string, counts []int) bool {
+					if d := levenshtein(needle, word); d <= maxEdits {
+						want = append(want, FuzzyMatch{Word: word, Counts: counts, Distance: d})
+					}
+					return true
+				})
+				sort.SliceStable(want, func(i int, j int) bool { return want[i].Distance < want[j].Distance })
+
+				got := finder.FindFuzzy(needle, maxEdits)
+				if len(want) == 0 {
+					assert.Empty(t, got, name)
+				} else {
+					assert.Equal(t, want, got, name)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkFindFuzzy(b *testing.B) {
+	tree, _ := New(2)
+	words := benchmarkWords(100000)
+	for i, word := range words {
+		_ = tree.Insert(word, i%2)
+	}
+
+	finder := tree.(FuzzyFinder)
+	for _, maxEdits := range []int{1, 2} {
+		b.Run(string(rune('0'+maxEdits)), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				word := words[i%len(words)]
+				finder.FindFuzzy(word[:len(word)-1]+"z", maxEdits)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package radix
+
+import (
+	"encoding/gob"
+	"errors"
+	"hash/fnv"
+)
+
+// ErrInvalidBits is an error for when the bit width of a hashed tree is out of range
+var ErrInvalidBits = errors.New("radix: invalid bit width")
+
+// MaxHashBits is the widest bit width a hashed tree can have
+const MaxHashBits = 28
+
+// hashed is a tree that hashes words into a fixed number of buckets instead of storing them, so its memory stays
+// the same however many distinct words it sees. Words that hash to the same bucket share their counts. With signed
+// hashing half of the words count down instead of up, so that collisions cancel out on average instead of always
+// inflating the counts.
+type hashed struct {
+	Bits           uint
+	Signed         bool
+	NumCategories  int
+	CategoryTotals []int
+	// Buckets holds the counts of each bucket, one row of NumCategories counts per bucket
+	Buckets []int
+	// Registers is the HyperLogLog sketch that estimates the number of distinct words
+	Registers []uint8
+}
+
+func init() {
+	gob.Register(&hashed{})
+}
+
+// NewHashed creates a tree with 2^bits buckets. UniqueWords is a HyperLogLog estimate, and a word is found whenever
+// its bucket has counts, even if they came from other words.
+func NewHashed(numCategories int, bits uint, signed bool) (Tree, error) {
+	if numCategories <= 0 {
+		return nil, ErrInvalidCategoryCount
+	}
+
+	if bits == 0 || bits > MaxHashBits {
+		return nil, ErrInvalidBits
+	}
+
+	return &hashed{
+		Bits:           bits,
+		Signed:         signed,
+		NumCategories:  numCategories,
+		CategoryTotals: make([]int, numCategories, numCategories),
+		Buckets:        make([]int, numCategories<<bits, numCategories<<bits),
+		Registers:      newRegisters(),
+	}, nil
+}
+
+// hashWord hashes a word to 64 well mixed bits
+func hashWord(word string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(word))
+	return mix(h.Sum64())
+}
+
+// mix is the finalizer of splitmix64, it spreads every input bit over the whole output
+func mix(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// bucket returns the first count of a word's bucket and the sign its counts are stored with
+func (t *hashed) bucket(h uint64) (int, int) {
+	sign := 1
+	if t.Signed && h&1 == 1 {
+		sign = -1
+	}
+	return int(h>>(64-t.Bits)) * t.NumCategories, sign
+}
+
+// Insert adds one to the word's bucket in a category
+func (t *hashed) Insert(needle string, category int) error {
+	if category < 0 || category >= t.NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+
+	h := hashWord(needle)
+	start, sign := t.bucket(h)
+	t.Buckets[start+category] += sign
+	t.CategoryTotals[category]++
+	hllAdd(t.Registers, h)
+	return nil
+}
+
+// Remove takes one back from the word's bucket, it does not change the estimate of UniqueWords
+func (t *hashed) Remove(needle string, category int) error {
+	if category < 0 || category >= t.NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+
+	start, sign := t.bucket(hashWord(needle))
+	if t.Buckets[start+category]*sign <= 0 {
+		return ErrNotFound
+	}
+
+	t.Buckets[start+category] -= sign
+	t.CategoryTotals[category]--
+	return nil
+}
+
+// Find returns the counts of the word's bucket, collisions can make them negative with signed hashing, so they are
+// clamped to 0
+func (t *hashed) Find(needle string) ([]int, bool) {
+	start, sign := t.bucket(hashWord(needle))
+	counts := make([]int, t.NumCategories, t.NumCategories)
+	found := false
+	for i := range counts {
+		if count := t.Buckets[start+i] * sign; count > 0 {
+			counts[i] = count
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, false
+	}
+	return counts, true
+}
+
+// GetTotals fetches the totals associated with each category
+func (t *hashed) GetTotals() []int {
+	return t.CategoryTotals
+}
+
+// CategoryCount returns the number of categories we are tracking in this tree
+func (t *hashed) CategoryCount() int {
+	return t.NumCategories
+}
+
+// UniqueWords estimates the number of distinct words inserted with HyperLogLog
+func (t *hashed) UniqueWords() int {
+	return hllEstimate(t.Registers)
+}
+
+// Merge adds the counts of another hashed tree with the same bit width and signing
+func (t *hashed) Merge(other Tree) error {
+	o, ok := other.(*hashed)
+	if !ok || o.Bits != t.Bits || o.Signed != t.Signed || o.NumCategories != t.NumCategories {
+		return ErrNotMergeable
+	}
+
+	for i, count := range o.Buckets {
+		t.Buckets[i] += count
+	}
+	for i, total := range o.CategoryTotals {
+		t.CategoryTotals[i] += total
+	}
+	hllMerge(t.Registers, o.Registers)
+	return nil
+}
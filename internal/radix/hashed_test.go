@@ -0,0 +1,94 @@
+package radix
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHashed(t *testing.T) {
+	_, err := NewHashed(0, 10, false)
+	assert.Equal(t, ErrInvalidCategoryCount, err)
+	_, err = NewHashed(2, 0, false)
+	assert.Equal(t, ErrInvalidBits, err)
+	_, err = NewHashed(2, MaxHashBits+1, false)
+	assert.Equal(t, ErrInvalidBits, err)
+
+	tree, err := NewHashed(2, 10, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tree.CategoryCount())
+	assert.Equal(t, 0, tree.UniqueWords())
+	assert.Equal(t, ErrOutOfBoundsCategory, tree.Insert("word", 2))
+	assert.Equal(t, ErrOutOfBoundsCategory, tree.Insert("word", -1))
+}
+
+func TestHashedCounts(t *testing.T) {
+	for _, signed := range []bool{false, true} {
+		tree, err := NewHashed(2, 20, signed)
+		assert.NoError(t, err)
+
+		for _, word := range []string{"apple", "apple", "banana"} {
+			assert.NoError(t, tree.Insert(word, 0))
+		}
+		assert.NoError(t, tree.Insert("banana", 1))
+
+		counts, found := tree.Find("apple")
+		assert.True(t, found)
+		assert.Equal(t, []int{2, 0}, counts)
+		counts, found = tree.Find("banana")
+		assert.True(t, found)
+		assert.Equal(t, []int{1, 1}, counts)
+		_, found = tree.Find("cherry")
+		assert.False(t, found)
+		assert.Equal(t, []int{3, 1}, tree.GetTotals())
+		assert.Equal(t, 2, tree.UniqueWords())
+
+		remover := tree.(Remover)
+		assert.NoError(t, remover.Remove("banana", 1))
+		assert.Equal(t, ErrNotFound, remover.Remove("banana", 1))
+		assert.Equal(t, ErrNotFound, remover.Remove("cherry", 0))
+		counts, _ = tree.Find("banana")
+		assert.Equal(t, []int{1, 0}, counts)
+		assert.Equal(t, []int{3, 0}, tree.GetTotals())
+	}
+}
+
+func TestHashedMemoryIsBounded(t *testing.T) {
+	tree, err := NewHashed(2, 8, true)
+	assert.NoError(t, err)
+	h := tree.(*hashed)
+	buckets, registers := len(h.Buckets), len(h.Registers)
+
+	for i := 0; i < 100000; i++ {
+		assert.NoError(t, tree.Insert(fmt.Sprintf("garbage%d", i), i%2))
+	}
+	assert.Equal(t, buckets, len(h.Buckets))
+	assert.Equal(t, registers, len(h.Registers))
+	assert.Equal(t, []int{50000, 50000}, tree.GetTotals())
+
+	// with far more words than buckets, signed collisions cancel out instead of piling up
+	sum := 0
+	for _, count := range h.Buckets {
+		sum += count
+	}
+	assert.True(t, math.Abs(float64(sum)) < 5000, "%d", sum)
+}
+
+func TestHashedUniqueWordsEstimate(t *testing.T) {
+	for _, n := range []int{100, 10000, 200000} {
+		tree, err := NewHashed(1, 12, false)
+		assert.NoError(t, err)
+
+		for i := 0; i < n; i++ {
+			word := fmt.Sprintf("word%d", i)
+			// repeats do not change the estimate
+			assert.NoError(t, tree.Insert(word, 0))
+			assert.NoError(t, tree.Insert(word, 0))
+		}
+
+		estimate := tree.UniqueWords()
+		assert.InEpsilon(t, n, estimate, 0.03, "%d words estimated as %d", n, estimate)
+	}
+}
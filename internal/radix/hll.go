@@ -0,0 +1,54 @@
+package radix
+
+import (
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of hash bits that pick a HyperLogLog register, 2^14 registers give a standard error of
+// about 0.8%
+const hllPrecision = 14
+
+// newRegisters makes the registers of an empty HyperLogLog sketch
+func newRegisters() []uint8 {
+	return make([]uint8, 1<<hllPrecision, 1<<hllPrecision)
+}
+
+// hllAdd records a hashed word in the registers
+func hllAdd(registers []uint8, h uint64) {
+	// the register comes from hashing the hash again, so it does not depend on how the caller uses the hash
+	h = mix(h)
+	idx := h >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(h<<hllPrecision|1<<(hllPrecision-1))) + 1
+	if rank > registers[idx] {
+		registers[idx] = rank
+	}
+}
+
+// hllMerge makes dst count the words of both sketches
+func hllMerge(dst []uint8, src []uint8) {
+	for i, r := range src {
+		if r > dst[i] {
+			dst[i] = r
+		}
+	}
+}
+
+// hllEstimate estimates the number of distinct words recorded in the registers
+func hllEstimate(registers []uint8) int {
+	m := float64(len(registers))
+	sum, zeros := 0.0, 0
+	for _, r := range registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// small cardinalities are estimated better by how many registers are still empty
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(estimate))
+}
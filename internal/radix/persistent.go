@@ -0,0 +1,309 @@
+package radix
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Snapshotter is implemented by trees that can be read while they are written, a snapshot never changes however much
+// the tree is written afterwards
+type Snapshotter interface {
+	Snapshot() Tree
+	// SameVersion reports whether another tree holds the same version as this one, as a snapshot taken of this tree
+	// does until this tree is written to
+	SameVersion(other Tree) bool
+}
+
+// Batcher is implemented by trees that publish each write to readers as it is made, a batch keeps its writes private
+// and publishes them all at once so that readers see either none or all of it
+type Batcher interface {
+	// Begin starts a batch, the writes made until Commit or Rollback are not seen by readers
+	Begin()
+	// Commit publishes every write of the batch at once
+	Commit()
+	// Rollback drops every write of the batch
+	Rollback()
+}
+
+// persistent is a radix tree that is never changed in place. Each write copies the nodes on the path to the word and
+// publishes a new version of the tree that shares every other node with the old one, so readers load the current
+// version and read it without locks while a writer builds the next one. Writers are serialized by a mutex, and a
+// batch of writes is built on a private version that is published once.
+type persistent struct {
+	mu      sync.Mutex
+	current atomic.Value
+	// pending is the version a batch is building, nil outside of a batch
+	pending *root
+}
+
+func init() {
+	gob.Register(&persistent{})
+}
+
+// NewPersistent creates a radix tree that can be read while it is written and snapshotted in constant time
+func NewPersistent(numCategories int) (Tree, error) {
+	tree, err := New(numCategories)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &persistent{}
+	t.current.Store(tree.(*root))
+	return t, nil
+}
+
+// load returns the current version, it must not be changed
+func (t *persistent) load() *root {
+	return t.current.Load().(*root)
+}
+
+// next starts a new version from the current one, the totals are copied and the nodes are shared
+func (t *persistent) next() *root {
+	r := t.load()
+	return &root{
+		NumCategories:    r.NumCategories,
+		CategoryTotals:   append([]int(nil), r.CategoryTotals...),
+		UniqueWordsCount: r.UniqueWordsCount,
+		Root:             r.Root,
+		CategoryTypes:    append([]int(nil), r.CategoryUniqueWords()...),
+	}
+}
+
+// writable returns the version a write goes into, the batch's own version or a new one
+func (t *persistent) writable() *root {
+	if t.pending != nil {
+		return t.pending
+	}
+	return t.next()
+}
+
+// publish makes a written version the current one, unless it belongs to a batch
+func (t *persistent) publish(r *root) {
+	if t.pending == nil {
+		t.current.Store(r)
+	}
+}
+
+// Insert publishes a version of the tree with the word counted once more in the category
+func (t *persistent) Insert(needle string, category int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if category < 0 || category >= t.load().NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+
+	next := t.writable()
+	var isNew, first bool
+	next.Root, isNew, first = insertCopy(next.Root, needle, next.NumCategories, category)
+	if isNew {
+		next.UniqueWordsCount++
+	}
+	if first {
+		next.CategoryTypes[category]++
+	}
+	next.CategoryTotals[category]++
+
+	t.publish(next)
+	return nil
+}
+
+// Remove publishes a version of the tree with one insert of the word taken back
+func (t *persistent) Remove(needle string, category int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if category < 0 || category >= t.load().NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+
+	next := t.writable()
+	n := next.find(needle)
+	if n == nil || n.Values == nil || n.Values[category] == 0 {
+		return ErrNotFound
+	}
+
+	var gone bool
+	if n.Values[category] == 1 {
+		next.CategoryTypes[category]--
+	}
+	next.Root, gone = removeCopy(next.Root, needle, category)
+	if gone {
+		next.UniqueWordsCount--
+	}
+	next.CategoryTotals[category]--
+
+	t.publish(next)
+	return nil
+}
+
+// Begin starts a batch on a private version of the tree
+func (t *persistent) Begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = t.next()
+}
+
+// Commit publishes the version the batch built
+func (t *persistent) Commit() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending != nil {
+		t.current.Store(t.pending)
+		t.pending = nil
+	}
+}
+
+// Rollback drops the version the batch built, the current version stays as it was before the batch
+func (t *persistent) Rollback() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = nil
+}
+
+// Find gets the category values associated with a given string in the current version
+func (t *persistent) Find(needle string) ([]int, bool) {
+	return t.load().Find(needle)
+}
+
+// GetTotals fetches the totals of each category in the current version
+func (t *persistent) GetTotals() []int {
+	return t.load().GetTotals()
+}
+
+// CategoryCount returns the number of categories we are tracking in this tree
+func (t *persistent) CategoryCount() int {
+	return t.load().NumCategories
+}
+
+// UniqueWords returns the number of words in the current version
+func (t *persistent) UniqueWords() int {
+	return t.load().UniqueWords()
+}
+
+// CategoryUniqueWords returns the number of distinct words seen in each category in the current version
+func (t *persistent) CategoryUniqueWords() []int {
+	return t.load().CategoryUniqueWords()
+}
+
+// Walk visits every word of the current version in lexical order, writes made during the walk are not seen
+func (t *persistent) Walk(fn func(word string, counts []int) bool) {
+	t.load().Walk(fn)
+}
+
+// Snapshot returns the current version as a tree of its own, writing to either tree afterwards does not change the
+// other
+func (t *persistent) Snapshot() Tree {
+	s := &persistent{}
+	s.current.Store(t.load())
+	return s
+}
+
+// SameVersion reports whether another tree is a persistent tree holding the same version as this one
+func (t *persistent) SameVersion(other Tree) bool {
+	o, ok := other.(*persistent)
+	return ok && o.load() == t.load()
+}
+
+// GobEncode encodes the current version
+func (t *persistent) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(t.next()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode decodes a version and makes it the current one
+func (t *persistent) GobDecode(data []byte) error {
+	r := &root{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(r); err != nil {
+		return err
+	}
+
+	t.current.Store(r)
+	return nil
+}
+
+// insertCopy returns a copy of the node with the word inserted below it, along with whether the word is new and
+// whether it is the word's first count in the category. Only the nodes on the path to the word are copied.
+func insertCopy(n *node, remainder string, numCategories int, category int) (*node, bool, bool) {
+	copied := &node{Values: n.Values, IsLeaf: n.IsLeaf, Children: n.Children}
+	if remainder == "" {
+		isNew := !n.IsLeaf
+		copied.IsLeaf = true
+		copied.Values = make([]int, numCategories, numCategories)
+		copy(copied.Values, n.Values)
+		copied.Values[category]++
+		return copied, isNew, copied.Values[category] == 1
+	}
+
+	// the children are shared with the old version, so they are copied before any of them is replaced
+	copied.Children = append([]child(nil), n.Children...)
+	leaf := func() *node {
+		values := make([]int, numCategories, numCategories)
+		values[category] = 1
+		return &node{IsLeaf: true, Values: values}
+	}
+
+	idx, match, lcp := searchChildren(n.Children, remainder)
+	switch match {
+	case exact, substring:
+		var isNew, first bool
+		copied.Children[idx].Node, isNew, first = insertCopy(n.Children[idx].Node, strings.TrimPrefix(remainder, lcp),
+			numCategories, category)
+		return copied, isNew, first
+	case sharedPrefix:
+		// the old child keeps its node under what is left of its prefix, next to a leaf for the word
+		old := n.Children[idx]
+		newChildren := []child{
+			{Prefix: strings.TrimPrefix(old.Prefix, lcp), Node: old.Node},
+			{Prefix: strings.TrimPrefix(remainder, lcp), Node: leaf()},
+		}
+		sort.Slice(newChildren, func(i int, j int) bool {
+			return newChildren[i].Prefix < newChildren[j].Prefix
+		})
+		copied.Children[idx] = child{Prefix: lcp, Node: &node{Children: newChildren}}
+	case super:
+		// the word is a prefix of the child, so it becomes the child's parent
+		old := n.Children[idx]
+		newNode := leaf()
+		newNode.Children = []child{{Prefix: strings.TrimPrefix(old.Prefix, lcp), Node: old.Node}}
+		copied.Children[idx] = child{Prefix: lcp, Node: newNode}
+	default:
+		copied.Children = insertChild(copied.Children, child{Prefix: remainder, Node: leaf()}, idx)
+	}
+	return copied, true, true
+}
+
+// removeCopy returns a copy of the node with one count of the word taken back, along with whether the word has no
+// counts left. The word must have a count in the category.
+func removeCopy(n *node, remainder string, category int) (*node, bool) {
+	copied := &node{Values: n.Values, IsLeaf: n.IsLeaf, Children: n.Children}
+	if remainder == "" {
+		copied.Values = append([]int(nil), n.Values...)
+		copied.Values[category]--
+		for _, value := range copied.Values {
+			if value != 0 {
+				return copied, false
+			}
+		}
+
+		// like the mutable tree, the node keeps its place and just stops representing a word
+		copied.IsLeaf = false
+		return copied, true
+	}
+
+	copied.Children = append([]child(nil), n.Children...)
+	idx, _, lcp := searchChildren(n.Children, remainder)
+	var gone bool
+	copied.Children[idx].Node, gone = removeCopy(n.Children[idx].Node, strings.TrimPrefix(remainder, lcp), category)
+	return copied, gone
+}
@@ -0,0 +1,224 @@
+package radix
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPersistent(t *testing.T, categories int) Tree {
+	tree, err := NewPersistent(categories)
+	assert.NoError(t, err)
+	return tree
+}
+
+func TestPersistentTree(t *testing.T) {
+	runTreeTests(t, newTestPersistent)
+
+	_, err := NewPersistent(0)
+	assert.Equal(t, ErrInvalidCategoryCount, err)
+}
+
+func TestPersistentSnapshot(t *testing.T) {
+	tree := newTestPersistent(t, 2)
+	assert.NoError(t, tree.Insert("spam", 1))
+	assert.NoError(t, tree.Insert("spammer", 1))
+
+	snapshot := tree.(Snapshotter).Snapshot()
+	assert.NoError(t, tree.Insert("spam", 0))
+	assert.NoError(t, tree.Insert("span", 0))
+	assert.NoError(t, tree.(Remover).Remove("spammer", 1))
+
+	// the snapshot still holds what was inserted before it
+	counts, found := snapshot.Find("spam")
+	assert.True(t, found)
+	assert.Equal(t, []int{0, 1}, counts)
+	counts, found = snapshot.Find("spammer")
+	assert.True(t, found)
+	assert.Equal(t, []int{0, 1}, counts)
+	_, found = snapshot.Find("span")
+	assert.False(t, found)
+	assert.Equal(t, 2, snapshot.UniqueWords())
+	assert.Equal(t, []int{0, 2}, snapshot.GetTotals())
+	assert.Equal(t, []int{0, 2}, snapshot.(TypeCounter).CategoryUniqueWords())
+
+	counts, found = tree.Find("spam")
+	assert.True(t, found)
+	assert.Equal(t, []int{1, 1}, counts)
+	_, found = tree.Find("spammer")
+	assert.False(t, found)
+	assert.Equal(t, 2, tree.UniqueWords())
+	assert.Equal(t, []int{2, 1}, tree.GetTotals())
+
+	// snapshots of one version are the same version until either is written to
+	current := tree.(Snapshotter).Snapshot()
+	assert.True(t, tree.(Snapshotter).SameVersion(current))
+	assert.True(t, current.(Snapshotter).SameVersion(tree))
+	assert.False(t, tree.(Snapshotter).SameVersion(snapshot))
+	other, err := New(2)
+	assert.NoError(t, err)
+	assert.False(t, tree.(Snapshotter).SameVersion(other))
+
+	// and writing to the snapshot does not change the tree
+	assert.NoError(t, snapshot.Insert("spa", 0))
+	_, found = tree.Find("spa")
+	assert.False(t, found)
+}
+
+func TestPersistentMatchesMemory(t *testing.T) {
+	memory, err := New(3)
+	assert.NoError(t, err)
+	tree := newTestPersistent(t, 3)
+
+	rng := rand.New(rand.NewSource(1))
+	var words []string
+	for i := 0; i < 20000; i++ {
+		category := rng.Intn(3)
+		if len(words) > 0 && rng.Intn(4) == 0 {
+			word := words[rng.Intn(len(words))]
+			assert.Equal(t, memory.(Remover).Remove(word, category), tree.(Remover).Remove(word, category))
+			continue
+		}
+
+		word := randString()
+		words = append(words, word)
+		assert.NoError(t, memory.Insert(word, category))
+		assert.NoError(t, tree.Insert(word, category))
+	}
+
+	assert.Equal(t, memory.UniqueWords(), tree.UniqueWords())
+	assert.Equal(t, memory.GetTotals(), tree.GetTotals())
+	assert.Equal(t, memory.(TypeCounter).CategoryUniqueWords(), tree.(TypeCounter).CategoryUniqueWords())
+
+	var want, got []string
+	memory.(Walker).Walk(func(word string, counts []int) bool {
+		want = append(want, word)
+		return true
+	})
+	tree.(Walker).Walk(func(word string, counts []int) bool {
+		got = append(got, word)
+		found, ok := memory.Find(word)
+		assert.True(t, ok)
+		assert.Equal(t, found, counts)
+		return true
+	})
+	assert.Equal(t, want, got)
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(&tree))
+	var decoded Tree
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	assert.Equal(t, tree.UniqueWords(), decoded.UniqueWords())
+	assert.Equal(t, tree.GetTotals(), decoded.GetTotals())
+	for _, word := range words[:100] {
+		counts, found := tree.Find(word)
+		decodedCounts, decodedFound := decoded.Find(word)
+		assert.Equal(t, found, decodedFound)
+		assert.Equal(t, counts, decodedCounts)
+	}
+	assert.NoError(t, decoded.Insert("decoded", 0))
+}
+
+func TestPersistentConcurrentReaders(t *testing.T) {
+	tree := newTestPersistent(t, 2)
+	words := make([]string, 2000)
+	for i := range words {
+		words[i] = randString()
+	}
+
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				snapshot := tree.(Snapshotter).Snapshot()
+				unique, totals := snapshot.UniqueWords(), snapshot.GetTotals()
+				if counts, found := snapshot.Find(words[i]); found {
+					assert.True(t, counts[0] > 0)
+				}
+
+				// a snapshot is consistent however much is written meanwhile
+				seen := 0
+				snapshot.(Walker).Walk(func(word string, counts []int) bool {
+					seen++
+					return true
+				})
+				assert.Equal(t, unique, seen)
+				assert.Equal(t, unique, snapshot.UniqueWords())
+				assert.Equal(t, totals, snapshot.GetTotals())
+			}
+		}()
+	}
+
+	for _, word := range words {
+		assert.NoError(t, tree.Insert(word, 0))
+	}
+	wg.Wait()
+}
+
+func TestPersistentBatch(t *testing.T) {
+	tree := newTestPersistent(t, 2)
+	assert.NoError(t, tree.Insert("keep", 1))
+	batcher := tree.(Batcher)
+
+	// writes of a batch are not seen until it is committed
+	batcher.Begin()
+	assert.NoError(t, tree.Insert("new", 0))
+	assert.NoError(t, tree.Insert("keep", 0))
+	assert.NoError(t, tree.(Remover).Remove("new", 0))
+	assert.NoError(t, tree.Insert("new", 1))
+	_, found := tree.Find("new")
+	assert.False(t, found)
+	assert.Equal(t, []int{0, 1}, tree.GetTotals())
+	batcher.Commit()
+
+	counts, found := tree.Find("new")
+	assert.True(t, found)
+	assert.Equal(t, []int{0, 1}, counts)
+	counts, _ = tree.Find("keep")
+	assert.Equal(t, []int{1, 1}, counts)
+	assert.Equal(t, []int{1, 2}, tree.GetTotals())
+	assert.Equal(t, 2, tree.UniqueWords())
+	assert.Equal(t, []int{1, 2}, tree.(TypeCounter).CategoryUniqueWords())
+
+	// a rolled back batch leaves the tree as it was
+	batcher.Begin()
+	assert.NoError(t, tree.Insert("gone", 0))
+	assert.NoError(t, tree.(Remover).Remove("keep", 0))
+	batcher.Rollback()
+	_, found = tree.Find("gone")
+	assert.False(t, found)
+	counts, _ = tree.Find("keep")
+	assert.Equal(t, []int{1, 1}, counts)
+	assert.Equal(t, []int{1, 2}, tree.GetTotals())
+
+	// outside of a batch every write is published
+	assert.NoError(t, tree.Insert("gone", 0))
+	_, found = tree.Find("gone")
+	assert.True(t, found)
+}
+
+func BenchmarkPersistentInsert(b *testing.B) {
+	words := make([]string, 10000)
+	for i := range words {
+		words[i] = randString()
+	}
+
+	for _, bench := range []struct {
+		name string
+		new  func(int) (Tree, error)
+	}{{"Memory", New}, {"Persistent", NewPersistent}} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			tree, _ := bench.new(2)
+			for i := 0; i < b.N; i++ {
+				_ = tree.Insert(words[i%len(words)], i%2)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"sort"
 	"strings"
+	"sync"
 	"unicode/utf8"
 )
 
@@ -18,11 +19,55 @@ type Tree interface {
 	UniqueWords() int
 }
 
+// Remover is implemented by trees that can take back an insert, it is what lets a failed batch be rolled back
+type Remover interface {
+	// Remove decrements the count of a word in a category, undoing one call to Insert
+	Remove(needle string, category int) error
+}
+
+// Checker is implemented by trees that cannot take back an insert, it tells before anything changes whether an insert
+// would fail, so that a batch can be checked as a whole first
+type Checker interface {
+	// CheckInsert returns the error Insert would return for the word in a category, an insert it accepts cannot fail
+	CheckInsert(needle string, category int) error
+}
+
+// TypeCounter is implemented by trees that know how many distinct words were seen in each category
+type TypeCounter interface {
+	CategoryUniqueWords() []int
+}
+
+// Walker is implemented by trees that can enumerate every word they hold
+type Walker interface {
+	// Walk calls fn for each word in lexical order until fn returns false, the counts must not be modified
+	Walk(fn func(word string, counts []int) bool)
+}
+
+// Merger is implemented by trees that can add the counts of another tree of the same kind, so that trees built by
+// separate workers can be combined
+type Merger interface {
+	Merge(other Tree) error
+}
+
+// Vocabulary is implemented by trees that give each word an integer ID, which stays the same however many words are
+// inserted afterwards
+type Vocabulary interface {
+	// ID returns the ID of a word in the tree
+	ID(word string) (uint32, bool)
+	// IDBytes is ID for a word held in bytes, it does not allocate
+	IDBytes(word []byte) (uint32, bool)
+	// FindID gets the category values of the word with an ID
+	FindID(id uint32) ([]int, bool)
+}
+
 type root struct {
 	NumCategories    int
 	CategoryTotals   []int
 	UniqueWordsCount int
 	Root             *node
+	// CategoryTypes counts the distinct words seen in each category, trees saved before it existed rebuild it lazily
+	CategoryTypes []int
+	rebuildTypes  sync.Once
 }
 
 type child struct {
@@ -45,6 +90,12 @@ var ErrInvalidCategoryCount = errors.New("radix: invalid category count")
 // ErrCannotCreateNode is an error we get when insert somehow fails
 var ErrCannotCreateNode = errors.New("radix: no node created")
 
+// ErrNotMergeable is an error for when two trees are of different kinds or shapes and cannot be merged
+var ErrNotMergeable = errors.New("radix: trees cannot be merged")
+
+// ErrNotFound is an error for when we try to remove a word that has no count in the category
+var ErrNotFound = errors.New("radix: word not found")
+
 type matchType string
 
 const (
@@ -61,26 +112,31 @@ func New(numCategories int) (Tree, error) {
 		return nil, ErrInvalidCategoryCount
 	}
 
+	// the root only gets counts once the empty word is inserted, so that it is not taken for a removed word
 	return &root{
 		NumCategories:  numCategories,
 		CategoryTotals: make([]int, numCategories, numCategories),
-		Root:           &node{IsLeaf: false, Values: make([]int, numCategories, numCategories)},
+		CategoryTypes:  make([]int, numCategories, numCategories),
+		Root:           &node{IsLeaf: false},
 	}, nil
 }
 
 // Insert creates or finds a node representing this string in this radix tree and increments the category
 func (r *root) Insert(needle string, category int) error {
-	if category >= r.NumCategories {
+	if category < 0 || category >= r.NumCategories {
 		return ErrOutOfBoundsCategory
 	}
 
-	node, isNew := r.findOrCreate(needle)
+	node, isNew := r.Root.findOrCreate(needle)
 	if node != nil {
 		if node.Values == nil {
 			node.Values = make([]int, r.NumCategories, r.NumCategories)
 		}
 
 		node.Values[category]++
+		if node.Values[category] == 1 && len(r.CategoryTypes) == r.NumCategories {
+			r.CategoryTypes[category]++
+		}
 
 		if isNew {
 			r.UniqueWordsCount++
@@ -94,6 +150,77 @@ func (r *root) Insert(needle string, category int) error {
 	return ErrCannotCreateNode
 }
 
+// Remove decrements the count of a word in a category, the word stops being counted as unique once it has no counts
+func (r *root) Remove(needle string, category int) error {
+	if category < 0 || category >= r.NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+
+	node := r.find(needle)
+	if node == nil || node.Values == nil || node.Values[category] == 0 {
+		return ErrNotFound
+	}
+
+	node.Values[category]--
+	r.CategoryTotals[category]--
+	if node.Values[category] == 0 && len(r.CategoryTypes) == r.NumCategories {
+		r.CategoryTypes[category]--
+	}
+
+	for _, value := range node.Values {
+		if value != 0 {
+			return nil
+		}
+	}
+
+	// the node stays in place to keep the tree's shape, it just stops representing a word
+	node.IsLeaf = false
+	r.UniqueWordsCount--
+	return nil
+}
+
+// addCounts adds a whole vector of counts to a word in one descent, or takes them off when sign is negative, in which
+// case the word must hold at least those counts
+func (r *root) addCounts(needle string, counts []int, sign int) {
+	var n *node
+	if sign > 0 {
+		var isNew bool
+		n, isNew = r.Root.findOrCreate(needle)
+		if n.Values == nil {
+			n.Values = make([]int, r.NumCategories, r.NumCategories)
+		}
+		if isNew {
+			r.UniqueWordsCount++
+		}
+	} else if n = r.find(needle); n == nil {
+		return
+	}
+
+	empty := true
+	for category, count := range counts {
+		before := n.Values[category]
+		n.Values[category] += sign * count
+		r.CategoryTotals[category] += sign * count
+
+		if len(r.CategoryTypes) == r.NumCategories {
+			if before == 0 && n.Values[category] > 0 {
+				r.CategoryTypes[category]++
+			} else if before > 0 && n.Values[category] == 0 {
+				r.CategoryTypes[category]--
+			}
+		}
+
+		if n.Values[category] != 0 {
+			empty = false
+		}
+	}
+
+	if empty {
+		n.IsLeaf = false
+		r.UniqueWordsCount--
+	}
+}
+
 // Find gets the category values associated with a given string
 func (r *root) Find(needle string) ([]int, bool) {
 	node := r.find(needle)
@@ -114,11 +241,63 @@ func (r *root) CategoryCount() int {
 	return r.NumCategories
 }
 
+// CategoryUniqueWords returns the number of distinct words seen in each category
+func (r *root) CategoryUniqueWords() []int {
+	r.rebuildTypes.Do(func() {
+		if len(r.CategoryTypes) == r.NumCategories {
+			return
+		}
+
+		types := make([]int, r.NumCategories, r.NumCategories)
+		r.Walk(func(word string, counts []int) bool {
+			for i, count := range counts {
+				if count > 0 {
+					types[i]++
+				}
+			}
+			return true
+		})
+		r.CategoryTypes = types
+	})
+
+	return r.CategoryTypes
+}
+
 // UniqueWords returns the number of words represented in this trie
 func (r *root) UniqueWords() int {
 	return r.UniqueWordsCount
 }
 
+// Walk visits every word in the tree in lexical order
+func (r *root) Walk(fn func(word string, counts []int) bool) {
+	r.Root.walk("", fn)
+}
+
+// walk does a depth first traversal below this node, and reports whether the traversal should continue
+func (n *node) walk(prefix string, fn func(word string, counts []int) bool) bool {
+	return n.visit(prefix, func(word string, leaf *node) bool {
+		return fn(word, leaf.Values)
+	})
+}
+
+// visit calls fn for every word node below this node in lexical order, and reports whether the traversal should
+// continue
+func (n *node) visit(prefix string, fn func(word string, leaf *node) bool) bool {
+	if n.IsLeaf {
+		if !fn(prefix, n) {
+			return false
+		}
+	}
+
+	for _, c := range n.Children {
+		if !c.Node.visit(prefix+c.Prefix, fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func longestCommonPrefix(left, right string) string {
 	if len(left) > len(right) {
 		temp := left
@@ -190,26 +369,35 @@ func searchChildren(children []child, needle string) (int, matchType, string) {
 
 // find searches through the tree and finds the node that represents this string, if it exists
 func (r *root) find(needle string) *node {
-	current := r.Root
+	current := r.Root.lookup(needle)
+	if current == nil || !current.IsLeaf {
+		return nil
+	}
+	return current
+}
+
+// removed reports whether a word had counts once and has none left, its node is still in the tree
+func (r *root) removed(needle string) bool {
+	current := r.Root.lookup(needle)
+	return current != nil && !current.IsLeaf && current.Values != nil
+}
+
+// lookup finds the node at the end of the path spelling the needle below this node, whether or not it is a word
+func (n *node) lookup(needle string) *node {
+	current := n
 	remainder := needle
 
 	// we loop until we either find the correct node, or we definitively cannot find it
-	for {
-		if remainder == "" {
-			if current.IsLeaf {
-				return current
-			}
-			return nil
-		}
-
+	for remainder != "" {
 		idx, match, lcp := searchChildren(current.Children, remainder)
-		if match == exact || match == substring {
-			current = current.Children[idx].Node
-			remainder = strings.TrimPrefix(remainder, lcp)
-		} else {
+		if match != exact && match != substring {
 			return nil
 		}
+
+		current = current.Children[idx].Node
+		remainder = strings.TrimPrefix(remainder, lcp)
 	}
+	return current
 }
 
 // inserts a new leaf at the specified index
@@ -220,15 +408,18 @@ func insertChild(children []child, newLeaf child, idx int) []child {
 	return children
 }
 
-// findOrCreate returns either an existing node representing the string, or creates a new one, the bool reports whether the node is new
-func (r *root) findOrCreate(needle string) (*node, bool) {
-	current := r.Root
+// findOrCreate returns either an existing node representing the string below this node, or creates a new one, the
+// bool reports whether the node is new
+func (n *node) findOrCreate(needle string) (*node, bool) {
+	current := n
 	remainder := needle
 	// we loop until we find either a node where we need to insert our string, or a node that already represents it
 	for {
 		if remainder == "" {
+			// an interior node created by a split only becomes a word once it is inserted itself
+			isNew := !current.IsLeaf
 			current.IsLeaf = true
-			return current, false
+			return current, isNew
 		}
 		idx, match, lcp := searchChildren(current.Children, remainder)
 		// if we find an exact match for the key, or just a substring prefix, we just keep looping
@@ -58,10 +58,37 @@ func truncate(s string, maxChars int) string {
 
 const iterations = 10000
 
-func TestInsertAndFetch(t *testing.T) {
+// treeTests is the suite every Tree implementation has to pass
+var treeTests = []struct {
+	name string
+	test func(t *testing.T, newTree func(categories int) Tree)
+}{
+	{"InsertAndFetch", testInsertAndFetch},
+	{"InsertOutOfBounds", testInsertOutOfBounds},
+	{"Walk", testWalk},
+	{"Remove", testRemove},
+	{"CategoryUniqueWords", testCategoryUniqueWords},
+}
 
-	tree, err := New(1)
-	assert.NoError(t, err)
+// runTreeTests runs the suite against the trees made by newTree
+func runTreeTests(t *testing.T, newTree func(t *testing.T, categories int) Tree) {
+	for _, tt := range treeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, func(categories int) Tree { return newTree(t, categories) })
+		})
+	}
+}
+
+func TestTree(t *testing.T) {
+	runTreeTests(t, func(t *testing.T, categories int) Tree {
+		tree, err := New(categories)
+		assert.NoError(t, err)
+		return tree
+	})
+}
+
+func testInsertAndFetch(t *testing.T, newTree func(int) Tree) {
+	tree := newTree(1)
 	assert.NotNil(t, tree)
 	rand.Seed(time.Now().Unix())
 	words := make(map[string]struct{})
@@ -106,6 +133,21 @@ func TestInsertAndFetch(t *testing.T) {
 	}
 }
 
+func TestInsertIntoSplitNodeCountsUniqueWord(t *testing.T) {
+	tree, err := New(1)
+	assert.NoError(t, err)
+
+	// "ap" is the interior node that splits "apple" and "apricot" until it is inserted itself
+	for _, word := range []string{"apple", "apricot", "ap", "ap"} {
+		assert.NoError(t, tree.Insert(word, 0))
+	}
+	assert.Equal(t, 3, tree.UniqueWords())
+
+	// the root stands for the empty word
+	assert.NoError(t, tree.Insert("", 0))
+	assert.Equal(t, 4, tree.UniqueWords())
+}
+
 func BenchmarkInsert(b *testing.B) {
 	b.ReportAllocs()
 	tree, err := New(1)
@@ -136,3 +178,129 @@ func BenchmarkMap(b *testing.B) {
 		words[randString()] = node{Values: make([]int, 1, 1)}
 	}
 }
+
+func testInsertOutOfBounds(t *testing.T, newTree func(int) Tree) {
+	tree := newTree(2)
+	assert.Equal(t, ErrOutOfBoundsCategory, tree.Insert("word", -1))
+	assert.Equal(t, ErrOutOfBoundsCategory, tree.Insert("word", 2))
+	assert.Equal(t, 0, tree.UniqueWords())
+}
+
+func testWalk(t *testing.T, newTree func(int) Tree) {
+	tree := newTree(2)
+
+	for _, word := range []string{"apple", "apricot", "ap", "banana", "apple"} {
+		assert.NoError(t, tree.Insert(word, 0))
+	}
+	assert.NoError(t, tree.Insert("banana", 1))
+	assert.Equal(t, 4, tree.UniqueWords())
+
+	var words []string
+	var counts [][]int
+	tree.(Walker).Walk(func(word string, c []int) bool {
+		words = append(words, word)
+		counts = append(counts, append([]int(nil), c...))
+		return true
+	})
+	assert.Equal(t, []string{"ap", "apple", "apricot", "banana"}, words)
+	assert.Equal(t, [][]int{{1, 0}, {2, 0}, {1, 0}, {1, 1}}, counts)
+
+	// returning false stops the walk early
+	visited := 0
+	tree.(Walker).Walk(func(string, []int) bool {
+		visited++
+		return visited < 2
+	})
+	assert.Equal(t, 2, visited)
+}
+
+func testRemove(t *testing.T, newTree func(int) Tree) {
+	tree := newTree(2)
+	remover := tree.(Remover)
+
+	assert.NoError(t, tree.Insert("apple", 0))
+	assert.NoError(t, tree.Insert("apple", 1))
+	assert.NoError(t, tree.Insert("apricot", 0))
+	assert.Equal(t, 2, tree.UniqueWords())
+
+	assert.NoError(t, remover.Remove("apple", 0))
+	counts, found := tree.Find("apple")
+	assert.True(t, found)
+	assert.Equal(t, []int{0, 1}, counts)
+	assert.Equal(t, []int{1, 1}, tree.GetTotals())
+
+	assert.NoError(t, remover.Remove("apple", 1))
+	_, found = tree.Find("apple")
+	assert.False(t, found)
+	assert.Equal(t, 1, tree.UniqueWords())
+	assert.Equal(t, []int{1, 0}, tree.GetTotals())
+
+	assert.Equal(t, ErrNotFound, remover.Remove("apple", 1))
+	assert.Equal(t, ErrNotFound, remover.Remove("ap", 0))
+	assert.Equal(t, ErrOutOfBoundsCategory, remover.Remove("apricot", 5))
+
+	// a removed word can be learned again
+	assert.NoError(t, tree.Insert("apple", 1))
+	assert.Equal(t, 2, tree.UniqueWords())
+}
+
+func testCategoryUniqueWords(t *testing.T, newTree func(int) Tree) {
+	tree := newTree(2)
+
+	for _, word := range []string{"a", "a", "b", "c"} {
+		assert.NoError(t, tree.Insert(word, 0))
+	}
+	assert.NoError(t, tree.Insert("a", 1))
+	assert.Equal(t, []int{3, 1}, tree.(TypeCounter).CategoryUniqueWords())
+
+	assert.NoError(t, tree.(Remover).Remove("a", 1))
+	assert.NoError(t, tree.(Remover).Remove("a", 0))
+	assert.Equal(t, []int{3, 0}, tree.(TypeCounter).CategoryUniqueWords())
+}
+
+func TestCategoryUniqueWordsDecoded(t *testing.T) {
+	tree, err := New(2)
+	assert.NoError(t, err)
+
+	for _, word := range []string{"a", "a", "b", "c"} {
+		assert.NoError(t, tree.Insert(word, 0))
+	}
+
+	// trees decoded from before the counts were tracked rebuild them on demand
+	decoded := &root{NumCategories: 2, CategoryTotals: tree.GetTotals(), Root: tree.(*root).Root}
+	assert.Equal(t, []int{3, 0}, decoded.CategoryUniqueWords())
+	assert.NoError(t, decoded.Insert("d", 1))
+	assert.Equal(t, []int{3, 1}, decoded.CategoryUniqueWords())
+}
+
+func TestAddCounts(t *testing.T) {
+	tree, err := New(3)
+	assert.NoError(t, err)
+	r := tree.(*root)
+
+	r.addCounts("word", []int{2, 0, 5}, 1)
+	r.addCounts("other", []int{0, 1, 0}, 1)
+	counts, found := r.Find("word")
+	assert.True(t, found)
+	assert.Equal(t, []int{2, 0, 5}, counts)
+	assert.Equal(t, []int{2, 1, 5}, r.GetTotals())
+	assert.Equal(t, []int{1, 1, 1}, r.CategoryUniqueWords())
+	assert.Equal(t, 2, r.UniqueWords())
+
+	r.addCounts("word", []int{2, 0, 1}, -1)
+	counts, _ = r.Find("word")
+	assert.Equal(t, []int{0, 0, 4}, counts)
+	assert.Equal(t, []int{0, 1, 1}, r.CategoryUniqueWords())
+
+	// taking off everything a word has stops it being a word
+	r.addCounts("word", []int{0, 0, 4}, -1)
+	_, found = r.Find("word")
+	assert.False(t, found)
+	assert.True(t, r.removed("word"))
+	assert.Equal(t, 1, r.UniqueWords())
+	assert.Equal(t, []int{0, 1, 0}, r.GetTotals())
+
+	// a word that is not there is left alone
+	r.addCounts("missing", []int{1, 0, 0}, -1)
+	assert.Equal(t, []int{0, 1, 0}, r.GetTotals())
+}
@@ -0,0 +1,164 @@
+package radix
+
+import (
+	"encoding/gob"
+	"errors"
+	"math"
+)
+
+// ErrInvalidErrorBounds is an error for when the error bounds of a sketch are not between 0 and 1
+var ErrInvalidErrorBounds = errors.New("radix: invalid error bounds")
+
+// maxSketchCounters is the most counters a sketch can have in one category
+const maxSketchCounters = 1 << 28
+
+// sketch is a tree that keeps a Count-Min Sketch per category instead of the words, so its memory depends only on the
+// error bounds. Counts are never underestimated, and are overestimated by at most epsilon times the category's total
+// with probability 1-delta.
+type sketch struct {
+	Width          int
+	Depth          int
+	NumCategories  int
+	CategoryTotals []int
+	// Counters holds Depth rows of Width counters for each category, category by category
+	Counters []int
+	// Registers is the HyperLogLog sketch that estimates the number of distinct words
+	Registers []uint8
+}
+
+func init() {
+	gob.Register(&sketch{})
+}
+
+// NewSketch creates a tree of Count-Min Sketches with e/epsilon counters in each of ln(1/delta) rows. A word's count
+// in a category is overestimated by more than epsilon times the total of the category with probability at most
+// delta, and never underestimated. UniqueWords is a HyperLogLog estimate.
+func NewSketch(numCategories int, epsilon float64, delta float64) (Tree, error) {
+	if numCategories <= 0 {
+		return nil, ErrInvalidCategoryCount
+	}
+
+	if !(epsilon > 0 && epsilon < 1) || !(delta > 0 && delta < 1) {
+		return nil, ErrInvalidErrorBounds
+	}
+
+	width := int(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+	if width*depth > maxSketchCounters {
+		return nil, ErrInvalidErrorBounds
+	}
+
+	return &sketch{
+		Width:          width,
+		Depth:          depth,
+		NumCategories:  numCategories,
+		CategoryTotals: make([]int, numCategories, numCategories),
+		Counters:       make([]int, numCategories*depth*width, numCategories*depth*width),
+		Registers:      newRegisters(),
+	}, nil
+}
+
+// cells fills idx with the counter of the word in each row of a category
+func (t *sketch) cells(h uint64, category int, idx []int) {
+	base := category * t.Depth * t.Width
+	// double hashing gives each row its own hash function from the two halves of one hash
+	h1, h2 := h&0xffffffff, h>>32|1
+	for row := range idx {
+		idx[row] = base + row*t.Width + int((h1+uint64(row)*h2)%uint64(t.Width))
+	}
+}
+
+// estimate is the smallest of the counters of the word in a category
+func (t *sketch) estimate(idx []int) int {
+	min := t.Counters[idx[0]]
+	for _, i := range idx[1:] {
+		if t.Counters[i] < min {
+			min = t.Counters[i]
+		}
+	}
+	return min
+}
+
+// CheckInsert returns the error Insert would return, only a category out of bounds makes it fail
+func (t *sketch) CheckInsert(needle string, category int) error {
+	if category < 0 || category >= t.NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+	return nil
+}
+
+// Insert adds one to the word in a category with a conservative update, only the counters that are at the current
+// estimate are raised, which keeps the overestimate of other words down
+func (t *sketch) Insert(needle string, category int) error {
+	if err := t.CheckInsert(needle, category); err != nil {
+		return err
+	}
+
+	h := hashWord(needle)
+	idx := make([]int, t.Depth)
+	t.cells(h, category, idx)
+
+	next := t.estimate(idx) + 1
+	for _, i := range idx {
+		if t.Counters[i] < next {
+			t.Counters[i] = next
+		}
+	}
+
+	t.CategoryTotals[category]++
+	hllAdd(t.Registers, h)
+	return nil
+}
+
+// Find estimates the counts of a word in every category
+func (t *sketch) Find(needle string) ([]int, bool) {
+	h := hashWord(needle)
+	idx := make([]int, t.Depth)
+	counts := make([]int, t.NumCategories, t.NumCategories)
+	found := false
+	for category := range counts {
+		t.cells(h, category, idx)
+		counts[category] = t.estimate(idx)
+		if counts[category] > 0 {
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, false
+	}
+	return counts, true
+}
+
+// GetTotals fetches the totals associated with each category
+func (t *sketch) GetTotals() []int {
+	return t.CategoryTotals
+}
+
+// CategoryCount returns the number of categories we are tracking in this tree
+func (t *sketch) CategoryCount() int {
+	return t.NumCategories
+}
+
+// UniqueWords estimates the number of distinct words inserted with HyperLogLog
+func (t *sketch) UniqueWords() int {
+	return hllEstimate(t.Registers)
+}
+
+// Merge adds the counts of a sketch with the same error bounds. Summing the counters keeps every count an
+// overestimate, and the error stays within epsilon times the combined total.
+func (t *sketch) Merge(other Tree) error {
+	o, ok := other.(*sketch)
+	if !ok || o.Width != t.Width || o.Depth != t.Depth || o.NumCategories != t.NumCategories {
+		return ErrNotMergeable
+	}
+
+	for i, count := range o.Counters {
+		t.Counters[i] += count
+	}
+	for i, total := range o.CategoryTotals {
+		t.CategoryTotals[i] += total
+	}
+	hllMerge(t.Registers, o.Registers)
+	return nil
+}
@@ -0,0 +1,134 @@
+package radix
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSketch(t *testing.T) {
+	_, err := NewSketch(0, 0.01, 0.01)
+	assert.Equal(t, ErrInvalidCategoryCount, err)
+	for _, bounds := range [][2]float64{{0, 0.01}, {1, 0.01}, {0.01, 0}, {0.01, 1}, {1e-12, 0.01}} {
+		_, err = NewSketch(2, bounds[0], bounds[1])
+		assert.Equal(t, ErrInvalidErrorBounds, err, "%v", bounds)
+	}
+
+	tree, err := NewSketch(2, 0.001, 0.01)
+	assert.NoError(t, err)
+	s := tree.(*sketch)
+	assert.Equal(t, 2719, s.Width)
+	assert.Equal(t, 5, s.Depth)
+	assert.Equal(t, ErrOutOfBoundsCategory, tree.Insert("word", 2))
+	_, found := tree.Find("word")
+	assert.False(t, found)
+
+	assert.Equal(t, ErrOutOfBoundsCategory, tree.(Checker).CheckInsert("word", 2))
+	assert.NoError(t, tree.(Checker).CheckInsert("word", 1))
+
+	// a view of a sketch checks inserts against the sketch's categories
+	view, err := NewView(tree, 1, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, view.(Checker).CheckInsert("word", 0))
+	assert.Equal(t, ErrOutOfBoundsCategory, view.(Checker).CheckInsert("word", 1))
+}
+
+// zipfStream inserts a skewed stream of words into every tree and returns the exact tree
+func zipfStream(t *testing.T, seed int64, n int, trees ...Tree) Tree {
+	exact, err := New(2)
+	assert.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(seed))
+	zipf := rand.NewZipf(rng, 1.2, 1, 100000)
+	for i := 0; i < n; i++ {
+		word := fmt.Sprintf("w%d", zipf.Uint64())
+		category := rng.Intn(2)
+		assert.NoError(t, exact.Insert(word, category))
+		for _, tree := range trees {
+			assert.NoError(t, tree.Insert(word, category))
+		}
+	}
+	return exact
+}
+
+// assertErrorBounds checks that no count of the sketch is below the exact count, and that at most a delta share of
+// them are more than epsilon times the category total above it
+func assertErrorBounds(t *testing.T, exact Tree, approx Tree, epsilon float64, delta float64) {
+	totals := exact.GetTotals()
+	assert.Equal(t, totals, approx.GetTotals())
+
+	words, over := 0, 0
+	exact.(Walker).Walk(func(word string, counts []int) bool {
+		estimates, found := approx.Find(word)
+		assert.True(t, found)
+		for i, count := range counts {
+			assert.True(t, estimates[i] >= count, "%s is underestimated", word)
+			if float64(estimates[i]-count) > epsilon*float64(totals[i]) {
+				over++
+			}
+			words++
+		}
+		return true
+	})
+
+	assert.True(t, float64(over) <= delta*float64(words), "%d of %d counts are over the bound", over, words)
+	assert.InEpsilon(t, exact.UniqueWords(), approx.UniqueWords(), 0.03)
+}
+
+func TestSketchErrorBounds(t *testing.T) {
+	const epsilon, delta = 0.0005, 0.01
+	tree, err := NewSketch(2, epsilon, delta)
+	assert.NoError(t, err)
+
+	exact := zipfStream(t, 1, 200000, tree)
+	assertErrorBounds(t, exact, tree, epsilon, delta)
+
+	// the most frequent words are estimated almost exactly
+	counts, _ := exact.Find("w1")
+	estimates, _ := tree.Find("w1")
+	assert.InDeltaSlice(t, counts, estimates, epsilon*float64(exact.GetTotals()[0]))
+}
+
+func TestSketchMerge(t *testing.T) {
+	const epsilon, delta = 0.0005, 0.01
+	merged, err := NewSketch(2, epsilon, delta)
+	assert.NoError(t, err)
+	worker, err := NewSketch(2, epsilon, delta)
+	assert.NoError(t, err)
+
+	first := zipfStream(t, 1, 100000, merged)
+	// the exact tree of the first stream learns the second one too, so it holds the counts of both
+	zipfStream(t, 2, 100000, worker, first)
+	assert.NoError(t, merged.(Merger).Merge(worker))
+	assertErrorBounds(t, first, merged, epsilon, delta)
+
+	other, err := NewSketch(2, 0.01, delta)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrNotMergeable, merged.(Merger).Merge(other))
+	assert.Equal(t, ErrNotMergeable, merged.(Merger).Merge(first))
+}
+
+func TestHashedMerge(t *testing.T) {
+	left, err := NewHashed(2, 20, true)
+	assert.NoError(t, err)
+	right, err := NewHashed(2, 20, true)
+	assert.NoError(t, err)
+
+	assert.NoError(t, left.Insert("apple", 0))
+	assert.NoError(t, right.Insert("apple", 0))
+	assert.NoError(t, right.Insert("banana", 1))
+	assert.NoError(t, left.(Merger).Merge(right))
+
+	counts, _ := left.Find("apple")
+	assert.Equal(t, []int{2, 0}, counts)
+	counts, _ = left.Find("banana")
+	assert.Equal(t, []int{0, 1}, counts)
+	assert.Equal(t, []int{2, 1}, left.GetTotals())
+	assert.Equal(t, 2, left.UniqueWords())
+
+	unsigned, err := NewHashed(2, 20, false)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrNotMergeable, left.(Merger).Merge(unsigned))
+}
@@ -0,0 +1,126 @@
+package radix
+
+// view exposes a contiguous range of another tree's categories as a tree of its own, so several models can share one
+// vocabulary
+type view struct {
+	tree   Tree
+	offset int
+	n      int
+}
+
+// NewView creates a tree backed by categories [offset, offset+n) of another tree. The vocabulary is shared, so
+// UniqueWords counts the words of the whole tree. A view is not a Snapshotter even when the other tree is, readers of
+// a view need the same lock as its writers.
+func NewView(tree Tree, offset int, n int) (Tree, error) {
+	if n <= 0 || offset < 0 || offset+n > tree.CategoryCount() {
+		return nil, ErrInvalidCategoryCount
+	}
+
+	return &view{tree: tree, offset: offset, n: n}, nil
+}
+
+// Insert increments the category in the underlying tree
+func (v *view) Insert(needle string, category int) error {
+	if category < 0 || category >= v.n {
+		return ErrOutOfBoundsCategory
+	}
+	return v.tree.Insert(needle, v.offset+category)
+}
+
+// CheckInsert returns the error Insert would return, as far as the underlying tree can tell without changing
+func (v *view) CheckInsert(needle string, category int) error {
+	if category < 0 || category >= v.n {
+		return ErrOutOfBoundsCategory
+	}
+
+	if checker, ok := v.tree.(Checker); ok {
+		return checker.CheckInsert(needle, v.offset+category)
+	}
+	return nil
+}
+
+// Find returns the word's counts for the categories of this view
+func (v *view) Find(needle string) ([]int, bool) {
+	counts, found := v.tree.Find(needle)
+	if !found {
+		return nil, false
+	}
+	return counts[v.offset : v.offset+v.n], true
+}
+
+// GetTotals returns the totals of the categories of this view
+func (v *view) GetTotals() []int {
+	return v.tree.GetTotals()[v.offset : v.offset+v.n]
+}
+
+// CategoryCount returns the number of categories in this view
+func (v *view) CategoryCount() int {
+	return v.n
+}
+
+// UniqueWords returns the size of the shared vocabulary
+func (v *view) UniqueWords() int {
+	return v.tree.UniqueWords()
+}
+
+// Remove decrements the category in the underlying tree, if it supports removal
+func (v *view) Remove(needle string, category int) error {
+	remover, ok := v.tree.(Remover)
+	if !ok {
+		return ErrNotFound
+	}
+
+	if category < 0 || category >= v.n {
+		return ErrOutOfBoundsCategory
+	}
+	return remover.Remove(needle, v.offset+category)
+}
+
+// CategoryUniqueWords returns the distinct words of the categories of this view, or nil if the underlying tree does not
+// count them
+func (v *view) CategoryUniqueWords() []int {
+	counter, ok := v.tree.(TypeCounter)
+	if !ok {
+		return nil
+	}
+	return counter.CategoryUniqueWords()[v.offset : v.offset+v.n]
+}
+
+// Walk visits every word of the shared vocabulary that has a count in one of the categories of this view
+func (v *view) Walk(fn func(word string, counts []int) bool) {
+	walker, ok := v.tree.(Walker)
+	if !ok {
+		return
+	}
+
+	walker.Walk(func(word string, counts []int) bool {
+		counts = counts[v.offset : v.offset+v.n]
+		for _, count := range counts {
+			if count != 0 {
+				return fn(word, counts)
+			}
+		}
+		return true
+	})
+}
+
+// FindFuzzy finds the words near a needle that have a count in one of the categories of this view, or nothing if the
+// underlying tree cannot find words by edit distance
+func (v *view) FindFuzzy(needle string, maxEdits int) []FuzzyMatch {
+	finder, ok := v.tree.(FuzzyFinder)
+	if !ok {
+		return nil
+	}
+
+	var matches []FuzzyMatch
+	for _, match := range finder.FindFuzzy(needle, maxEdits) {
+		match.Counts = match.Counts[v.offset : v.offset+v.n]
+		for _, count := range match.Counts {
+			if count != 0 {
+				matches = append(matches, match)
+				break
+			}
+		}
+	}
+	return matches
+}
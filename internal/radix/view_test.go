@@ -0,0 +1,53 @@
+package radix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestView(t *testing.T) {
+	tree, err := New(4)
+	assert.NoError(t, err)
+
+	first, err := NewView(tree, 0, 2)
+	assert.NoError(t, err)
+	second, err := NewView(tree, 2, 2)
+	assert.NoError(t, err)
+
+	assert.NoError(t, first.Insert("apple", 1))
+	assert.NoError(t, second.Insert("apple", 0))
+	assert.NoError(t, second.Insert("pear", 1))
+	assert.Equal(t, ErrOutOfBoundsCategory, first.Insert("apple", 2))
+
+	counts, found := tree.Find("apple")
+	assert.True(t, found)
+	assert.Equal(t, []int{0, 1, 1, 0}, counts)
+
+	counts, found = second.Find("apple")
+	assert.True(t, found)
+	assert.Equal(t, []int{1, 0}, counts)
+	assert.Equal(t, []int{0, 1}, first.GetTotals())
+	assert.Equal(t, []int{1, 1}, second.GetTotals())
+	assert.Equal(t, 2, first.CategoryCount())
+	assert.Equal(t, 2, first.UniqueWords())
+	assert.Equal(t, []int{1, 1}, second.(TypeCounter).CategoryUniqueWords())
+
+	var words []string
+	first.(Walker).Walk(func(word string, counts []int) bool {
+		words = append(words, word)
+		return true
+	})
+	assert.Equal(t, []string{"apple"}, words)
+
+	// fuzzy matches are limited to the words and categories of the view
+	assert.Equal(t, []FuzzyMatch{{Word: "apple", Counts: []int{0, 1}, Distance: 1}}, first.(FuzzyFinder).FindFuzzy("aple", 1))
+	assert.Equal(t, []FuzzyMatch{{Word: "pear", Counts: []int{0, 1}, Distance: 1}}, second.(FuzzyFinder).FindFuzzy("peat", 1))
+	assert.Empty(t, first.(FuzzyFinder).FindFuzzy("pear", 0))
+
+	assert.NoError(t, second.(Remover).Remove("pear", 1))
+	assert.Equal(t, 1, tree.UniqueWords())
+
+	_, err = NewView(tree, 3, 2)
+	assert.Equal(t, ErrInvalidCategoryCount, err)
+}
@@ -0,0 +1,209 @@
+package radix
+
+import (
+	"encoding/gob"
+	"errors"
+	"time"
+)
+
+// ErrInvalidWindow is an error for when a window has no epochs or its epochs have no length
+var ErrInvalidWindow = errors.New("radix: invalid window")
+
+// Windower is implemented by trees that only count what was inserted in their most recent epochs
+type Windower interface {
+	// Advance moves the window to the epoch now falls in, dropping the counts of the epochs that fall out of it, and
+	// returns how many epoch lengths it moved
+	Advance(now time.Time) int
+	// Epochs describes the live epochs from oldest to newest
+	Epochs() []Epoch
+}
+
+// Epoch describes what one epoch of a window holds
+type Epoch struct {
+	Start       time.Time
+	Totals      []int
+	UniqueWords int
+}
+
+// window is a tree that keeps a tree per epoch and only counts the most recent ones. The combined counts of the live
+// epochs are kept in an aggregate tree, so scoring does not look at the epochs at all, and dropping an epoch only
+// subtracts the counts of each of that epoch's words from the aggregate, one descent per distinct word.
+type window struct {
+	NumCategories int
+	Size          int
+	Length        time.Duration
+	// Starts holds the start of each live epoch in nanoseconds since the Unix epoch, oldest first
+	Starts    []int64
+	Live      []Tree
+	Aggregate Tree
+	// Dead counts the words dropped from the aggregate since it was last rebuilt
+	Dead int
+}
+
+func init() {
+	gob.Register(&window{})
+}
+
+// NewWindow creates a tree that counts the words inserted in the last size epochs of the given length, its first
+// epoch starts now
+func NewWindow(numCategories int, size int, length time.Duration, now time.Time) (Tree, error) {
+	if size <= 0 || length <= 0 {
+		return nil, ErrInvalidWindow
+	}
+
+	aggregate, err := New(numCategories)
+	if err != nil {
+		return nil, err
+	}
+
+	epoch, err := New(numCategories)
+	if err != nil {
+		return nil, err
+	}
+
+	return &window{
+		NumCategories: numCategories,
+		Size:          size,
+		Length:        length,
+		Starts:        []int64{now.UnixNano()},
+		Live:          []Tree{epoch},
+		Aggregate:     aggregate,
+	}, nil
+}
+
+// Insert counts the word in the current epoch
+func (t *window) Insert(needle string, category int) error {
+	if category < 0 || category >= t.NumCategories {
+		return ErrOutOfBoundsCategory
+	}
+
+	if err := t.Live[len(t.Live)-1].Insert(needle, category); err != nil {
+		return err
+	}
+
+	// a word learned again after its epochs were dropped is counted by its old node
+	if t.Aggregate.(*root).removed(needle) {
+		t.Dead--
+	}
+	return t.Aggregate.Insert(needle, category)
+}
+
+// Remove takes back an insert from the current epoch
+func (t *window) Remove(needle string, category int) error {
+	if err := t.Live[len(t.Live)-1].(Remover).Remove(needle, category); err != nil {
+		return err
+	}
+
+	if err := t.Aggregate.(Remover).Remove(needle, category); err != nil {
+		return err
+	}
+	if t.Aggregate.(*root).removed(needle) {
+		t.Dead++
+	}
+	return nil
+}
+
+// Find gets the counts of a word over the live epochs
+func (t *window) Find(needle string) ([]int, bool) {
+	return t.Aggregate.Find(needle)
+}
+
+// GetTotals fetches the totals of each category over the live epochs
+func (t *window) GetTotals() []int {
+	return t.Aggregate.GetTotals()
+}
+
+// CategoryCount returns the number of categories we are tracking in this tree
+func (t *window) CategoryCount() int {
+	return t.NumCategories
+}
+
+// UniqueWords returns the number of distinct words in the live epochs
+func (t *window) UniqueWords() int {
+	return t.Aggregate.UniqueWords()
+}
+
+// CategoryUniqueWords returns the number of distinct words in each category over the live epochs
+func (t *window) CategoryUniqueWords() []int {
+	return t.Aggregate.(TypeCounter).CategoryUniqueWords()
+}
+
+// Walk visits every word of the live epochs in lexical order
+func (t *window) Walk(fn func(word string, counts []int) bool) {
+	t.Aggregate.(Walker).Walk(fn)
+}
+
+// Advance starts a new epoch if at least one epoch length passed since the current one started, and drops the epochs
+// that are older than the window. Epochs start at whole multiples of the length after the first one however late
+// Advance is called, and epochs in which Advance was never called are simply empty.
+func (t *window) Advance(now time.Time) int {
+	last := t.Starts[len(t.Starts)-1]
+	n := (now.UnixNano() - last) / int64(t.Length)
+	if n <= 0 {
+		return 0
+	}
+
+	start := last + n*int64(t.Length)
+	cutoff := start - int64(t.Size-1)*int64(t.Length)
+	if last < cutoff {
+		// every live epoch falls out of the window, so there is nothing worth subtracting
+		t.Aggregate, _ = New(t.NumCategories)
+		t.Starts, t.Live, t.Dead = t.Starts[:0], t.Live[:0], 0
+	}
+
+	for len(t.Starts) > 0 && t.Starts[0] < cutoff {
+		t.drop()
+	}
+
+	epoch, _ := New(t.NumCategories)
+	t.Starts = append(t.Starts, start)
+	t.Live = append(t.Live, epoch)
+	return int(n)
+}
+
+// drop subtracts the oldest epoch from the aggregate and forgets it
+func (t *window) drop() {
+	aggregate := t.Aggregate.(*root)
+	t.Live[0].(Walker).Walk(func(word string, counts []int) bool {
+		aggregate.addCounts(word, counts, -1)
+		if _, found := aggregate.Find(word); !found {
+			t.Dead++
+		}
+		return true
+	})
+
+	t.Starts = append(t.Starts[:0], t.Starts[1:]...)
+	t.Live = append(t.Live[:0], t.Live[1:]...)
+
+	// removed words keep their nodes in the aggregate, so once there are more of those than live words it is worth
+	// rebuilding it from the live epochs
+	if t.Dead > t.Aggregate.UniqueWords() {
+		t.rebuild()
+	}
+}
+
+// rebuild recomputes the aggregate from the live epochs
+func (t *window) rebuild() {
+	t.Aggregate, _ = New(t.NumCategories)
+	t.Dead = 0
+	aggregate := t.Aggregate.(*root)
+	for _, epoch := range t.Live {
+		epoch.(Walker).Walk(func(word string, counts []int) bool {
+			aggregate.addCounts(word, counts, 1)
+			return true
+		})
+	}
+}
+
+// Epochs describes the live epochs from oldest to newest
+func (t *window) Epochs() []Epoch {
+	epochs := make([]Epoch, len(t.Live))
+	for i, epoch := range t.Live {
+		epochs[i] = Epoch{
+			Start:       time.Unix(0, t.Starts[i]),
+			Totals:      append([]int(nil), epoch.GetTotals()...),
+			UniqueWords: epoch.UniqueWords(),
+		}
+	}
+	return epochs
+}
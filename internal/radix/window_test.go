@@ -0,0 +1,152 @@
+package radix
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowTree(t *testing.T) {
+	runTreeTests(t, func(t *testing.T, categories int) Tree {
+		tree, err := NewWindow(categories, 3, time.Hour, time.Unix(0, 0))
+		assert.NoError(t, err)
+		return tree
+	})
+}
+
+func TestNewWindow(t *testing.T) {
+	_, err := NewWindow(2, 0, time.Hour, time.Now())
+	assert.Equal(t, ErrInvalidWindow, err)
+	_, err = NewWindow(2, 3, 0, time.Now())
+	assert.Equal(t, ErrInvalidWindow, err)
+	_, err = NewWindow(0, 3, time.Hour, time.Now())
+	assert.Equal(t, ErrInvalidCategoryCount, err)
+}
+
+func TestWindowAdvance(t *testing.T) {
+	start := time.Unix(0, 0)
+	tree, err := NewWindow(2, 3, time.Hour, start)
+	assert.NoError(t, err)
+	w := tree.(Windower)
+
+	assert.NoError(t, tree.Insert("first", 0))
+	assert.Equal(t, 0, w.Advance(start.Add(59*time.Minute)))
+
+	assert.Equal(t, 1, w.Advance(start.Add(90*time.Minute)))
+	assert.NoError(t, tree.Insert("second", 1))
+	assert.Equal(t, 2, w.Advance(start.Add(3*time.Hour)))
+	assert.NoError(t, tree.Insert("fourth", 0))
+
+	// the first epoch fell out of the window, the empty third one was never started
+	_, found := tree.Find("first")
+	assert.False(t, found)
+	counts, found := tree.Find("second")
+	assert.True(t, found)
+	assert.Equal(t, []int{0, 1}, counts)
+	assert.Equal(t, []int{1, 1}, tree.GetTotals())
+	assert.Equal(t, 2, tree.UniqueWords())
+
+	epochs := w.Epochs()
+	assert.Len(t, epochs, 2)
+	assert.True(t, start.Add(time.Hour).Equal(epochs[0].Start))
+	assert.Equal(t, []int{0, 1}, epochs[0].Totals)
+	assert.True(t, start.Add(3*time.Hour).Equal(epochs[1].Start))
+	assert.Equal(t, 1, epochs[1].UniqueWords)
+
+	// a gap longer than the window drops everything at once
+	assert.Equal(t, 10, w.Advance(start.Add(13*time.Hour)))
+	assert.Equal(t, 0, tree.UniqueWords())
+	assert.Equal(t, []int{0, 0}, tree.GetTotals())
+	assert.Len(t, w.Epochs(), 1)
+}
+
+func TestWindowDeadWords(t *testing.T) {
+	start := time.Unix(0, 0)
+	tree, err := NewWindow(2, 2, time.Hour, start)
+	assert.NoError(t, err)
+	w := tree.(*window)
+
+	for _, word := range []string{"spam", "ham", "eggs"} {
+		assert.NoError(t, tree.Insert(word, 0))
+	}
+	w.Advance(start.Add(time.Hour))
+	for _, word := range []string{"toast", "jam", "tea", "milk"} {
+		assert.NoError(t, tree.Insert(word, 1))
+	}
+	w.Advance(start.Add(2 * time.Hour))
+	assert.Equal(t, 3, w.Dead)
+
+	// learning a dropped word again brings its node back, and a word taken back leaves one behind
+	assert.NoError(t, tree.Insert("spam", 1))
+	assert.Equal(t, 2, w.Dead)
+	assert.NoError(t, tree.Insert("bacon", 1))
+	assert.NoError(t, tree.(Remover).Remove("bacon", 1))
+	assert.Equal(t, 3, w.Dead)
+	assert.Equal(t, removedWords(w.Aggregate.(*root).Root), w.Dead)
+}
+
+func TestWindowMatchesExactCounts(t *testing.T) {
+	const size = 4
+	start := time.Unix(0, 0)
+	tree, err := NewWindow(2, size, time.Hour, start)
+	assert.NoError(t, err)
+
+	type insert struct {
+		word     string
+		category int
+		epoch    int
+	}
+	var inserts []insert
+
+	rng := rand.New(rand.NewSource(1))
+	epoch := 0
+	for i := 0; i < 20000; i++ {
+		if rng.Intn(500) == 0 {
+			epoch += 1 + rng.Intn(2)
+			tree.(Windower).Advance(start.Add(time.Duration(epoch)*time.Hour + time.Minute))
+		}
+
+		word := fmt.Sprintf("w%d", rng.Intn(300)+epoch*20)
+		category := rng.Intn(2)
+		inserts = append(inserts, insert{word, category, epoch})
+		assert.NoError(t, tree.Insert(word, category))
+	}
+
+	exact, err := New(2)
+	assert.NoError(t, err)
+	for _, in := range inserts {
+		if in.epoch > epoch-size {
+			assert.NoError(t, exact.Insert(in.word, in.category))
+		}
+	}
+
+	assert.Equal(t, exact.GetTotals(), tree.GetTotals())
+	assert.Equal(t, exact.UniqueWords(), tree.UniqueWords())
+	assert.Equal(t, exact.(TypeCounter).CategoryUniqueWords(), tree.(TypeCounter).CategoryUniqueWords())
+	exact.(Walker).Walk(func(word string, counts []int) bool {
+		got, found := tree.Find(word)
+		assert.True(t, found)
+		assert.Equal(t, counts, got, word)
+		return true
+	})
+
+	// the aggregate is rebuilt once it holds more dropped words than live ones, and words learned again are no longer
+	// counted as dropped
+	assert.True(t, tree.(*window).Dead <= tree.UniqueWords())
+	assert.Equal(t, removedWords(tree.(*window).Aggregate.(*root).Root), tree.(*window).Dead)
+}
+
+// removedWords counts the nodes below n that were words and have no counts left
+func removedWords(n *node) int {
+	removed := 0
+	if !n.IsLeaf && n.Values != nil {
+		removed++
+	}
+	for _, c := range n.Children {
+		removed += removedWords(c.Node)
+	}
+	return removed
+}
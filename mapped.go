@@ -0,0 +1,395 @@
+package bayesian
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+)
+
+// A mapped model file is laid out so that it can be memory mapped and scored in place, nothing is decoded onto the
+// heap when it is opened. Every number is little endian and every section starts on an 8 byte boundary:
+//
+//	header         magic, version, categories, unknown policy, flags and the length of each table
+//	priors         categories float64 log priors
+//	unknown        categories float64 log probabilities of an unseen word
+//	labels         string table
+//	words          string table with categories float64 log probabilities per word
+//	shapes         string table with categories float64 log probabilities per word shape
+//
+// A string table is n+1 uint32 offsets into its bytes, then its rows of log probabilities, then the bytes of its
+// strings, which are sorted so lookups are a binary search.
+const (
+	mappedMagic   = "BAYESMM\x00"
+	mappedVersion = 1
+
+	mappedHeaderSize = 64
+
+	// mappedHasUnknown is set in the flags when unseen words have log probabilities, which they do for every
+	// unknown policy but UnknownIgnore
+	mappedHasUnknown = 1 << 0
+)
+
+// ErrInvalidMappedFile is an error we throw when a file is not a mapped model or is truncated
+var ErrInvalidMappedFile = errors.New("bayesian: invalid mapped model file")
+
+// MappedModel is a model scored in place from a memory mapped file. Its classifier is read only, and it must not be
+// used after Close.
+type MappedModel struct {
+	Model
+	m *mapped
+}
+
+// OpenMapped memory maps a file written by SaveMapped. Opening only reads the header, so it takes the same time no
+// matter how large the model is, and processes that open the same file share its pages.
+func OpenMapped(path string) (*MappedModel, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() < mappedHeaderSize || int64(int(info.Size())) != info.Size() {
+		return nil, ErrInvalidMappedFile
+	}
+
+	data, err := mmapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := parseMapped(data)
+	if err != nil {
+		_ = munmapFile(data)
+		return nil, err
+	}
+
+	labels := make([]string, m.labels.Len())
+	for i := range labels {
+		labels[i] = string(m.labels.word(i))
+	}
+
+	return &MappedModel{Model: Model{Labels: labels, Classifier: m}, m: m}, nil
+}
+
+// Close unmaps the file
+func (m *MappedModel) Close() error {
+	return munmapFile(m.m.data)
+}
+
+// SaveMapped writes the model in the memory mappable layout to a temporary file next to path and renames it into
+// place. The classifier is compiled first, so the file scores exactly like the classifier does now.
+func (m *Model) SaveMapped(path string) error {
+	return writeFile(path, m.WriteMapped)
+}
+
+// WriteMapped writes the model in the memory mappable layout to w
+func (m *Model) WriteMapped(w io.Writer) error {
+	c, ok := m.Classifier.(*compiled)
+	if !ok {
+		frozen, err := Compile(m.Classifier)
+		if err != nil {
+			return err
+		}
+		c = frozen.(*compiled)
+	}
+
+	if len(m.Labels) != c.categoryCount() {
+		return ErrLabelMismatch
+	}
+
+	labels := wordTable{}
+	for _, label := range m.Labels {
+		if err := labels.add(label, nil); err != nil {
+			return err
+		}
+	}
+
+	// a file with more bytes of words than its offsets address would parse, and then return the wrong words
+	for _, t := range []*wordTable{&labels, &c.Table, &c.Shapes} {
+		if uint64(len(t.Words)) > maxTableBytes {
+			return ErrModelTooLarge
+		}
+	}
+
+	mw := &mappedWriter{w: bufio.NewWriter(w)}
+
+	var flags uint32
+	unknown := c.UnknownLogProbs
+	if unknown != nil {
+		flags |= mappedHasUnknown
+	} else {
+		unknown = make([]float64, c.categoryCount())
+	}
+
+	header := make([]byte, mappedHeaderSize)
+	copy(header, mappedMagic)
+	binary.LittleEndian.PutUint32(header[8:], mappedVersion)
+	binary.LittleEndian.PutUint32(header[12:], uint32(c.categoryCount()))
+	binary.LittleEndian.PutUint32(header[16:], uint32(c.Unknown))
+	binary.LittleEndian.PutUint32(header[20:], flags)
+	binary.LittleEndian.PutUint64(header[24:], uint64(labels.Len()))
+	binary.LittleEndian.PutUint64(header[32:], uint64(c.Table.Len()))
+	binary.LittleEndian.PutUint64(header[40:], uint64(c.Shapes.Len()))
+	mw.write(header)
+
+	mw.floats(c.LogPriors)
+	mw.floats(unknown)
+	mw.table(&labels)
+	mw.table(&c.Table)
+	mw.table(&c.Shapes)
+
+	if mw.err != nil {
+		return mw.err
+	}
+	return mw.w.Flush()
+}
+
+// mappedWriter writes the sections of a mapped model, remembering the first error
+type mappedWriter struct {
+	w   *bufio.Writer
+	n   int
+	err error
+}
+
+func (mw *mappedWriter) write(b []byte) {
+	if mw.err != nil {
+		return
+	}
+	_, mw.err = mw.w.Write(b)
+	mw.n += len(b)
+}
+
+// pad aligns the next section to 8 bytes
+func (mw *mappedWriter) pad() {
+	if rem := mw.n % 8; rem != 0 {
+		mw.write(make([]byte, 8-rem))
+	}
+}
+
+func (mw *mappedWriter) floats(fs []float64) {
+	buf := make([]byte, 8)
+	for _, f := range fs {
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(f))
+		mw.write(buf)
+	}
+}
+
+func (mw *mappedWriter) table(t *wordTable) {
+	offsets := t.Offsets
+	if len(offsets) == 0 {
+		offsets = []uint32{0}
+	}
+
+	buf := make([]byte, 4)
+	for _, offset := range offsets {
+		binary.LittleEndian.PutUint32(buf, offset)
+		mw.write(buf)
+	}
+	mw.pad()
+	mw.floats(t.LogProbs)
+	mw.write(t.Words)
+	mw.pad()
+}
+
+// mappedTable is a string table read in place from the mapped bytes
+type mappedTable struct {
+	data       []byte
+	n          int
+	categories int
+	offsets    int
+	probs      int
+	words      int
+}
+
+// parse finds the sections of a table starting at pos and returns where the next section starts
+func (t *mappedTable) parse(data []byte, pos int, n int, categories int) (int, error) {
+	t.data, t.n, t.categories = data, n, categories
+
+	t.offsets = pos
+	pos += align((n + 1) * 4)
+	t.probs = pos
+	pos += n * categories * 8
+	t.words = pos
+	if pos > len(data) {
+		return 0, ErrInvalidMappedFile
+	}
+
+	pos += align(int(t.offset(n)))
+	if pos > len(data) {
+		return 0, ErrInvalidMappedFile
+	}
+	return pos, nil
+}
+
+// Len returns the number of strings in the table
+func (t *mappedTable) Len() int {
+	return t.n
+}
+
+func (t *mappedTable) offset(i int) uint32 {
+	return binary.LittleEndian.Uint32(t.data[t.offsets+i*4:])
+}
+
+// word returns the i-th string without copying it, or nil if its offsets are corrupt
+func (t *mappedTable) word(i int) []byte {
+	start, end := int(t.offset(i)), int(t.offset(i+1))
+	if start > end || t.words+end > len(t.data) {
+		return nil
+	}
+	return t.data[t.words+start : t.words+end]
+}
+
+// find returns the row of a string, or -1 if the table does not hold it
+func (t *mappedTable) find(needle string) int {
+	idx := sort.Search(t.n, func(i int) bool { return string(t.word(i)) >= needle })
+	if idx < t.n && string(t.word(idx)) == needle {
+		return idx
+	}
+	return -1
+}
+
+// row copies the log probabilities of the i-th string into dst
+func (t *mappedTable) row(i int, dst []float64) {
+	readFloats(t.data[t.probs+i*t.categories*8:], dst)
+}
+
+// mapped is the read only classifier of a mapped model file
+type mapped struct {
+	readOnly
+	data            []byte
+	categories      int
+	unknown         UnknownPolicy
+	priors          []float64
+	unknownLogProbs []float64
+	labels          mappedTable
+	words           mappedTable
+	shapes          mappedTable
+}
+
+// parseMapped checks the header and finds where each section starts, it does not look at the words themselves
+func parseMapped(data []byte) (*mapped, error) {
+	if len(data) < mappedHeaderSize || string(data[:8]) != mappedMagic {
+		return nil, ErrInvalidMappedFile
+	}
+
+	if binary.LittleEndian.Uint32(data[8:]) != mappedVersion {
+		return nil, ErrInvalidMappedFile
+	}
+
+	m := &mapped{
+		data:       data,
+		categories: int(binary.LittleEndian.Uint32(data[12:])),
+		unknown:    UnknownPolicy(binary.LittleEndian.Uint32(data[16:])),
+	}
+	flags := binary.LittleEndian.Uint32(data[20:])
+	counts := [3]uint64{}
+	for i := range counts {
+		counts[i] = binary.LittleEndian.Uint64(data[24+i*8:])
+		if counts[i] > uint64(len(data)) {
+			return nil, ErrInvalidMappedFile
+		}
+	}
+
+	if m.categories == 0 || m.categories > len(data) || m.unknown < UnknownSmooth || m.unknown > UnknownShape {
+		return nil, ErrInvalidMappedFile
+	}
+
+	pos := mappedHeaderSize + 2*m.categories*8
+	if pos > len(data) {
+		return nil, ErrInvalidMappedFile
+	}
+
+	m.priors = make([]float64, m.categories)
+	readFloats(data[mappedHeaderSize:], m.priors)
+	if flags&mappedHasUnknown != 0 {
+		m.unknownLogProbs = make([]float64, m.categories)
+		readFloats(data[mappedHeaderSize+m.categories*8:], m.unknownLogProbs)
+	}
+
+	var err error
+	if pos, err = m.labels.parse(data, pos, int(counts[0]), 0); err != nil {
+		return nil, err
+	}
+	if pos, err = m.words.parse(data, pos, int(counts[1]), m.categories); err != nil {
+		return nil, err
+	}
+	if _, err = m.shapes.parse(data, pos, int(counts[2]), m.categories); err != nil {
+		return nil, err
+	}
+
+	if m.labels.Len() != m.categories {
+		return nil, ErrLabelMismatch
+	}
+
+	return m, nil
+}
+
+func (m *mapped) categoryCount() int {
+	return m.categories
+}
+
+func (m *mapped) logPriors() []float64 {
+	return m.priors
+}
+
+func (m *mapped) wordLogProbs(word string, dst []float64) (bool, bool, error) {
+	if i := m.words.find(word); i >= 0 {
+		m.words.row(i, dst)
+		return true, true, nil
+	}
+
+	if m.unknownLogProbs == nil {
+		return false, false, nil
+	}
+
+	if m.unknown == UnknownShape {
+		if i := m.shapes.find(wordShape(word)); i >= 0 {
+			m.shapes.row(i, dst)
+			return false, true, nil
+		}
+	}
+
+	copy(dst, m.unknownLogProbs)
+	return false, true, nil
+}
+
+// Scores computes the probability that a given document belongs to each of the categories
+func (m *mapped) Scores(doc []string) ([]*big.Float, int, bool, error) {
+	result, err := m.Score(doc)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return result.Scores, result.Best, result.Strict, nil
+}
+
+// Score computes the posterior of each category along with how confident the decision is
+func (m *mapped) Score(doc []string) (*ScoreResult, error) {
+	return score(m, doc)
+}
+
+// Begin starts a transaction that can never be committed, a mapped classifier is read only
+func (m *mapped) Begin() *Tx {
+	return &Tx{c: m, categories: m.categories}
+}
+
+// align rounds n up to a multiple of 8
+func align(n int) int {
+	return (n + 7) &^ 7
+}
+
+// readFloats decodes little endian float64s from b into dst
+func readFloats(b []byte, dst []float64) {
+	for i := range dst {
+		dst[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[i*8:]))
+	}
+}
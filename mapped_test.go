@@ -0,0 +1,132 @@
+package bayesian
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMappedMatchesClassifier(t *testing.T) {
+	dir := t.TempDir()
+	for _, policy := range []UnknownPolicy{UnknownSmooth, UnknownIgnore, UnknownHapax, UnknownShape} {
+		c := syntheticCorpus(t, 3, 500, 60, WithSmoother(Dirichlet{Mu: 100}), WithUnknownPolicy(policy))
+		path := filepath.Join(dir, policy.String()+".bmm")
+		assert.NoError(t, (&Model{Labels: []string{"a", "b", "c"}, Classifier: c}).SaveMapped(path))
+
+		m, err := OpenMapped(path)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, m.Labels)
+
+		for _, doc := range append(syntheticDocs(20, 500), []string{"x9", "?"}, nil) {
+			want, err := c.Score(doc)
+			assert.NoError(t, err)
+			got, err := m.Classifier.Score(doc)
+			assert.NoError(t, err)
+			assert.Equal(t, want, got, "%v", policy)
+		}
+
+		assert.Equal(t, ErrReadOnly, m.Classifier.Learn([]string{"a"}, 0))
+		assert.Equal(t, ErrReadOnly, m.Classifier.Begin().Commit())
+		assert.NoError(t, m.Close())
+	}
+}
+
+func TestMappedFromCompiled(t *testing.T) {
+	frozen, err := Compile(syntheticCorpus(t, 2, 100, 10))
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "model.bmm")
+	assert.NoError(t, (&Model{Labels: []string{"ham", "spam"}, Classifier: frozen}).SaveMapped(path))
+
+	m, err := OpenMapped(path)
+	assert.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	category, ok := m.Category("spam")
+	assert.True(t, ok)
+	assert.Equal(t, 1, category)
+
+	want, err := frozen.Score([]string{"w1", "w2", "nope"})
+	assert.NoError(t, err)
+	got, err := m.Classifier.Score([]string{"w1", "w2", "nope"})
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestMappedErrors(t *testing.T) {
+	dir := t.TempDir()
+	c := syntheticCorpus(t, 2, 100, 10)
+
+	err := (&Model{Labels: []string{"one"}, Classifier: c}).SaveMapped(filepath.Join(dir, "labels.bmm"))
+	assert.Equal(t, ErrLabelMismatch, err)
+
+	untrained, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+	err = (&Model{Labels: []string{"a", "b"}, Classifier: untrained}).SaveMapped(filepath.Join(dir, "untrained.bmm"))
+	assert.Equal(t, ErrUntrained, err)
+
+	// a model with more bytes of words than the file's 32 bit offsets address is not written at all
+	frozen, err := Compile(c)
+	assert.NoError(t, err)
+	func() {
+		defer func(max uint64) { maxTableBytes = max }(maxTableBytes)
+		maxTableBytes = 64
+		var buf bytes.Buffer
+		err = (&Model{Labels: []string{"a", "b"}, Classifier: frozen}).WriteMapped(&buf)
+		assert.Equal(t, ErrModelTooLarge, err)
+		assert.Zero(t, buf.Len())
+	}()
+
+	_, err = OpenMapped(filepath.Join(dir, "missing.bmm"))
+	assert.True(t, os.IsNotExist(err))
+
+	path := filepath.Join(dir, "model.bmm")
+	assert.NoError(t, (&Model{Labels: []string{"a", "b"}, Classifier: c}).SaveMapped(path))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	corrupt := map[string][]byte{
+		"short":     data[:10],
+		"truncated": data[:len(data)-16],
+		"magic":     append([]byte("NOTAMODEL"), data[9:]...),
+	}
+	for name, b := range corrupt {
+		p := filepath.Join(dir, name+".bmm")
+		assert.NoError(t, os.WriteFile(p, b, 0o644))
+		_, err := OpenMapped(p)
+		assert.Equal(t, ErrInvalidMappedFile, err, name)
+	}
+}
+
+func BenchmarkOpenMapped(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "model.bmm")
+	c := syntheticCorpus(b, 4, 50000, 2000)
+	assert.NoError(b, (&Model{Labels: []string{"a", "b", "c", "d"}, Classifier: c}).SaveMapped(path))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m, err := OpenMapped(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = m.Close()
+	}
+}
+
+func BenchmarkLoadModelFile(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "model.gob")
+	c := syntheticCorpus(b, 4, 50000, 2000)
+	assert.NoError(b, (&Model{Labels: []string{"a", "b", "c", "d"}, Classifier: c}).SaveFile(path))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := LoadModelFile(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package bayesian
+
+import (
+	"errors"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// ErrNotMergeable is an error we throw when merging classifiers whose counts cannot be added together
+var ErrNotMergeable = errors.New("bayesian: classifiers cannot be merged")
+
+// Merge adds everything src learned to dst, so that workers can learn separate parts of a stream and be combined.
+// Both classifiers have to store their counts the same way, with WithCountMinSketch or WithFeatureHashing and the
+// same settings. dst is locked for writing and src for reading, so two classifiers must not be merged into each
+// other at the same time.
+func Merge(dst Classifier, src Classifier) error {
+	d, ok := dst.(*classifier)
+	if !ok {
+		return ErrNotMergeable
+	}
+
+	s, ok := src.(*classifier)
+	if !ok {
+		return ErrNotMergeable
+	}
+
+	merger, ok := d.Tree.(radix.Merger)
+	if !ok || d == s {
+		return ErrNotMergeable
+	}
+
+	dstLock, srcLock := d.lock(), s.lock()
+	dstLock.Lock()
+	defer dstLock.Unlock()
+	if srcLock != dstLock {
+		srcLock.RLock()
+		defer srcLock.RUnlock()
+	}
+
+	if err := merger.Merge(s.Tree); err != nil {
+		return ErrNotMergeable
+	}
+
+	d.resetUnknownCounts()
+	return nil
+}
@@ -0,0 +1,43 @@
+package bayesian
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	training := syntheticTraining(3, 2000, 60)
+
+	whole, err := NewClassifier(3, 1, WithCountMinSketch(0.0001, 0.01))
+	assert.NoError(t, err)
+	assert.NoError(t, whole.LearnBatch(training))
+
+	// two workers learn half of the documents each
+	workers := make([]Classifier, 2)
+	for i := range workers {
+		workers[i], err = NewClassifier(3, 1, WithCountMinSketch(0.0001, 0.01))
+		assert.NoError(t, err)
+	}
+	for i, doc := range training {
+		assert.NoError(t, workers[i%2].Learn(doc.Words, doc.Category))
+	}
+	assert.NoError(t, Merge(workers[0], workers[1]))
+
+	for _, doc := range syntheticDocs(20, 2000) {
+		want, err := whole.Score(doc)
+		assert.NoError(t, err)
+		got, err := workers[0].Score(doc)
+		assert.NoError(t, err)
+		assert.Equal(t, want.Best, got.Best)
+	}
+
+	exact, err := NewClassifier(3, 1)
+	assert.NoError(t, err)
+	other, err := NewClassifier(3, 1, WithCountMinSketch(0.01, 0.01))
+	assert.NoError(t, err)
+	assert.Equal(t, ErrNotMergeable, Merge(exact, exact))
+	assert.Equal(t, ErrNotMergeable, Merge(workers[0], workers[0]))
+	assert.Equal(t, ErrNotMergeable, Merge(workers[0], other))
+	assert.Equal(t, ErrNotMergeable, Merge(workers[0], exact))
+}
@@ -0,0 +1,15 @@
+package bayesian
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps size bytes of f read only and shared, so every process mapping the file reads the same page cache
+func mmapFile(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+func munmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
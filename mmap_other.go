@@ -0,0 +1,22 @@
+//go:build !linux
+
+package bayesian
+
+import (
+	"io"
+	"os"
+)
+
+// mmapFile reads the file into memory on platforms without mmap support here, the layout is the same so scoring
+// works unchanged
+func mmapFile(f *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func munmapFile(data []byte) error {
+	return nil
+}
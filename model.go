@@ -0,0 +1,130 @@
+package bayesian
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Model bundles a classifier with the names of its categories, which is what gets saved to and loaded from disk
+type Model struct {
+	Labels     []string
+	Classifier Classifier
+}
+
+// ErrNoLabels is an error we throw when a model is created without any category labels
+var ErrNoLabels = errors.New("bayesian: model has no labels")
+
+// ErrLabelMismatch is an error we throw when a decoded model has a different number of labels than categories
+var ErrLabelMismatch = errors.New("bayesian: labels do not match the categories of the classifier")
+
+// NewModel creates a new model with one category per label
+func NewModel(labels []string, smoothingFactor float64, opts ...Option) (*Model, error) {
+	if len(labels) == 0 {
+		return nil, ErrNoLabels
+	}
+
+	c, err := NewClassifier(len(labels), smoothingFactor, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Model{Labels: labels, Classifier: c}, nil
+}
+
+// Category finds the category index for a label
+func (m *Model) Category(label string) (int, bool) {
+	for i, l := range m.Labels {
+		if l == label {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Label returns the name of a category, or the empty string if it is out of range
+func (m *Model) Label(category int) string {
+	if category < 0 || category >= len(m.Labels) {
+		return ""
+	}
+	return m.Labels[category]
+}
+
+// Save writes the model to w as a gob stream
+func (m *Model) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(m)
+}
+
+// SaveFile writes the model to a temporary file next to path and renames it into place, so readers never see a
+// partially written model
+func (m *Model) SaveFile(path string) error {
+	return writeFile(path, m.Save)
+}
+
+// writeFile writes a file through a temporary file next to path that is synced and renamed into place. The file keeps
+// the mode of the one it replaces, a new file is made readable by everyone with mode 0644.
+func writeFile(path string, write func(io.Writer) error) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	// temporary files are only readable by their owner
+	if err := f.Chmod(mode); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// LoadModel reads a model written by Save
+func LoadModel(r io.Reader) (*Model, error) {
+	var m Model
+	if err := gob.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	if len(m.Labels) == 0 {
+		return nil, ErrNoLabels
+	}
+
+	if inspector, ok := m.Classifier.(Inspector); ok && len(inspector.Stats().CategoryTotals) != len(m.Labels) {
+		return nil, ErrLabelMismatch
+	}
+
+	return &m, nil
+}
+
+// LoadModelFile reads a model written by SaveFile
+func LoadModelFile(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	return LoadModel(f)
+}
@@ -0,0 +1,69 @@
+package bayesian
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testModel(t *testing.T) *Model {
+	t.Helper()
+	return &Model{Labels: []string{"ham", "spam"}, Classifier: trainedClassifier(t, 2, []Document{
+		{Words: []string{"meeting", "lunch", "report"}, Category: 0},
+		{Words: []string{"viagra", "winner", "lottery", "winner"}, Category: 1},
+	})}
+}
+
+func TestModelLabels(t *testing.T) {
+	m := testModel(t)
+
+	idx, ok := m.Category("spam")
+	assert.True(t, ok)
+	assert.Equal(t, 1, idx)
+	_, ok = m.Category("eggs")
+	assert.False(t, ok)
+
+	assert.Equal(t, "ham", m.Label(0))
+	assert.Equal(t, "", m.Label(2))
+
+	_, err := NewModel(nil, 1)
+	assert.Equal(t, ErrNoLabels, err)
+}
+
+func TestModelSaveLoad(t *testing.T) {
+	m := testModel(t)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, m.Save(buf))
+	loaded, err := LoadModel(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, m.Labels, loaded.Labels)
+	_, idx, _, err := loaded.Classifier.Scores([]string{"winner"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, idx)
+
+	path := filepath.Join(t.TempDir(), "model.gob")
+	assert.NoError(t, m.SaveFile(path))
+	loaded, err = LoadModelFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, m.Labels, loaded.Labels)
+
+	// a new file is readable by everyone, saving over a file keeps its mode
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	assert.NoError(t, os.Chmod(path, 0600))
+	assert.NoError(t, m.SaveFile(path))
+	info, err = os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	m.Labels = append(m.Labels, "extra")
+	buf.Reset()
+	assert.NoError(t, m.Save(buf))
+	_, err = LoadModel(buf)
+	assert.Equal(t, ErrLabelMismatch, err)
+}
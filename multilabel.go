@@ -0,0 +1,245 @@
+package bayesian
+
+import (
+	"encoding/gob"
+	"errors"
+	"sync"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// MultiLabelClassifier assigns any number of labels to a document, it keeps one binary classifier per label (one vs
+// rest) and all of them share a single vocabulary
+type MultiLabelClassifier interface {
+	// Learn learns a document as positive for the given labels and negative for every other label
+	Learn(doc []string, labels []int) error
+	// Scores returns the probability that each label applies to the document
+	Scores(doc []string) ([]float64, error)
+	// Predict returns the labels whose probability reaches their threshold, in increasing order
+	Predict(doc []string) ([]int, error)
+	// SetThreshold changes the probability a label needs to be predicted, it is 0.5 by default
+	SetThreshold(label int, threshold float64) error
+	// Labels is the number of labels the classifier tracks
+	Labels() int
+	// Label returns the binary classifier of a single label, learning through it only changes that label
+	Label(label int) (BinaryClassifier, error)
+}
+
+// ErrInvalidLabel is an error we throw when a label is out of range for a multi-label classifier
+var ErrInvalidLabel = errors.New("bayesian: invalid label")
+
+// ErrInvalidThreshold is an error we throw when a threshold is not a probability
+var ErrInvalidThreshold = errors.New("bayesian: invalid threshold")
+
+type multiLabel struct {
+	// Tree holds the counts of every label, label i uses category 2i for negative and 2i+1 for positive
+	Tree            radix.Tree
+	NumLabels       int
+	SmoothingFactor float64
+	Smoother        Smoother
+	Unknown         UnknownPolicy
+	FuzzyEdits      int
+	FuzzyPenalty    float64
+	Thresholds      []float64
+
+	// mu guards the shared tree, the per label classifiers use it as their lock as well
+	mu       sync.RWMutex
+	build    sync.Once
+	buildErr error
+	labels   []*classifier
+}
+
+func init() {
+	gob.Register(&multiLabel{})
+}
+
+// ErrUnsupportedOption is an error we throw when an option cannot be applied to a kind of classifier
+var ErrUnsupportedOption = errors.New("bayesian: option is not supported by this classifier")
+
+// NewMultiLabelClassifier creates a multi-label classifier for the given number of labels, the options apply to the
+// classifier of every label. The labels share one tree, so an option choosing how counts are stored, such as
+// WithArenaTree or WithFeatureHashing, stores the counts of every label that way. WithDecay, WithAutoDecay and
+// WithWindow move a clock each label would have to share, and return ErrUnsupportedOption. The labels see the shared
+// tree through views that cannot be snapshotted, so with WithPersistentTree scoring still waits for learning to finish.
+func NewMultiLabelClassifier(labels int, smoothingFactor float64, opts ...Option) (MultiLabelClassifier, error) {
+	if labels <= 0 {
+		return nil, ErrInvalidLabel
+	}
+
+	// the options are applied to a stand in classifier with a category for each side of each label, which validates
+	// them the same way and builds the shared tree
+	template, err := newClassifier(2*labels, smoothingFactor, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := template.Tree.(radix.Decayer); ok {
+		return nil, ErrUnsupportedOption
+	}
+	if _, ok := template.Tree.(radix.Windower); ok {
+		return nil, ErrUnsupportedOption
+	}
+
+	m := &multiLabel{
+		Tree:            template.Tree,
+		NumLabels:       labels,
+		SmoothingFactor: smoothingFactor,
+		Smoother:        template.Smoother,
+		Unknown:         template.Unknown,
+		FuzzyEdits:      template.FuzzyEdits,
+		FuzzyPenalty:    template.FuzzyPenalty,
+		Thresholds:      make([]float64, labels),
+	}
+	for i := range m.Thresholds {
+		m.Thresholds[i] = 0.5
+	}
+
+	if err := m.classifiers(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// classifiers builds the per label classifiers over views of the shared tree, once per process since the views are
+// not part of the encoded model
+func (m *multiLabel) classifiers() error {
+	m.build.Do(func() {
+		m.labels = make([]*classifier, m.NumLabels)
+		for i := range m.labels {
+			view, err := radix.NewView(m.Tree, 2*i, 2)
+			if err != nil {
+				m.buildErr = err
+				return
+			}
+			m.labels[i] = &classifier{
+				Tree:            view,
+				SmoothingFactor: m.SmoothingFactor,
+				Smoother:        m.Smoother,
+				Unknown:         m.Unknown,
+				FuzzyEdits:      m.FuzzyEdits,
+				FuzzyPenalty:    m.FuzzyPenalty,
+				shared:          &m.mu,
+			}
+		}
+	})
+	return m.buildErr
+}
+
+func (m *multiLabel) Labels() int {
+	return m.NumLabels
+}
+
+func (m *multiLabel) Label(label int) (BinaryClassifier, error) {
+	if label < 0 || label >= m.NumLabels {
+		return nil, ErrInvalidLabel
+	}
+
+	if err := m.classifiers(); err != nil {
+		return nil, err
+	}
+	return m.labels[label], nil
+}
+
+func (m *multiLabel) Learn(doc []string, labels []int) error {
+	positive := make([]bool, m.NumLabels)
+	for _, label := range labels {
+		if label < 0 || label >= m.NumLabels {
+			return ErrInvalidLabel
+		}
+		positive[label] = true
+	}
+
+	if err := m.classifiers(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, c := range m.labels {
+		category := Negative
+		if positive[i] {
+			category = Positive
+		}
+
+		if err := c.learnBatch([]Document{{Words: doc, Category: category}}); err != nil {
+			// labels learned so far are taken back so the document is learned by all labels or none
+			for j := i - 1; j >= 0; j-- {
+				category := Negative
+				if positive[j] {
+					category = Positive
+				}
+				m.labels[j].undo(nil, doc, category)
+				m.labels[j].resetUnknownCounts()
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Scores returns the positive posterior of every label, all of them read under one lock so they come from the same
+// counts
+func (m *multiLabel) Scores(doc []string) ([]float64, error) {
+	if err := m.classifiers(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.scores(doc)
+}
+
+// scores is Scores for callers that already hold the read lock
+func (m *multiLabel) scores(doc []string) ([]float64, error) {
+	probs := make([]float64, m.NumLabels)
+	for i, c := range m.labels {
+		result, err := c.score(doc)
+		if err != nil {
+			return nil, err
+		}
+		probs[i] = result.Posteriors[Positive]
+	}
+	return probs, nil
+}
+
+// Predict returns the labels whose positive posterior reaches their threshold, the scores and the thresholds are read
+// under the same lock so a concurrent Learn or SetThreshold is seen whole or not at all
+func (m *multiLabel) Predict(doc []string) ([]int, error) {
+	if err := m.classifiers(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	probs, err := m.scores(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := []int{}
+	for i, prob := range probs {
+		if prob >= m.Thresholds[i] {
+			labels = append(labels, i)
+		}
+	}
+	return labels, nil
+}
+
+func (m *multiLabel) SetThreshold(label int, threshold float64) error {
+	if label < 0 || label >= m.NumLabels {
+		return ErrInvalidLabel
+	}
+
+	if threshold < 0 || threshold > 1 {
+		return ErrInvalidThreshold
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Thresholds[label] = threshold
+	return nil
+}
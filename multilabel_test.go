@@ -0,0 +1,198 @@
+package bayesian
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	billing = iota
+	login
+	bug
+)
+
+// ticketDocs are the documents of ticketClassifier, a document is learned by every label its category has the bit of
+var ticketDocs = []Document{
+	{Words: []string{"refund", "invoice", "charged", "twice"}, Category: 1 << billing},
+	{Words: []string{"password", "reset", "locked", "out"}, Category: 1 << login},
+	{Words: []string{"app", "crash", "error", "stack"}, Category: 1 << bug},
+	{Words: []string{"charged", "after", "crash", "error"}, Category: 1<<billing | 1<<bug},
+	{Words: []string{"thanks", "great", "service"}, Category: 0},
+}
+
+func ticketClassifier(t *testing.T, opts ...Option) MultiLabelClassifier {
+	t.Helper()
+	m, err := NewMultiLabelClassifier(3, 1, opts...)
+	assert.NoError(t, err)
+
+	learnDocs(t, ticketDocs, func(words []string, category int) error {
+		var labels []int
+		for label := 0; label < m.Labels(); label++ {
+			if category&(1<<label) != 0 {
+				labels = append(labels, label)
+			}
+		}
+		return m.Learn(words, labels)
+	})
+	return m
+}
+
+func TestMultiLabel(t *testing.T) {
+	m := ticketClassifier(t)
+	assert.Equal(t, 3, m.Labels())
+
+	labels, err := m.Predict([]string{"refund", "invoice"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{billing}, labels)
+
+	labels, err = m.Predict([]string{"charged", "crash", "error"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{billing, bug}, labels)
+
+	labels, err = m.Predict([]string{"thanks", "service"})
+	assert.NoError(t, err)
+	assert.Empty(t, labels)
+
+	probs, err := m.Scores([]string{"password", "reset"})
+	assert.NoError(t, err)
+	assert.Len(t, probs, 3)
+	assert.True(t, probs[login] > 0.5)
+
+	// raising a threshold above the probability drops the label
+	assert.NoError(t, m.SetThreshold(login, 0.999999))
+	labels, err = m.Predict([]string{"password", "reset"})
+	assert.NoError(t, err)
+	assert.Empty(t, labels)
+
+	assert.Equal(t, ErrInvalidThreshold, m.SetThreshold(login, 2))
+	assert.Equal(t, ErrInvalidLabel, m.SetThreshold(3, 0.5))
+	assert.Equal(t, ErrInvalidLabel, m.Learn([]string{"x"}, []int{3}))
+	_, err = NewMultiLabelClassifier(0, 1)
+	assert.Equal(t, ErrInvalidLabel, err)
+}
+
+func TestMultiLabelOptions(t *testing.T) {
+	options := map[string][]Option{
+		"arena":      {WithArenaTree()},
+		"persistent": {WithPersistentTree()},
+		"hashing":    {WithFeatureHashing(16, false)},
+		"sketch":     {WithCountMinSketch(0.001, 0.01)},
+	}
+	for name, opts := range options {
+		m := ticketClassifier(t, opts...)
+		want, err := newClassifier(6, 1, opts)
+		assert.NoError(t, err)
+		assert.IsType(t, want.Tree, m.(*multiLabel).Tree, name)
+
+		labels, err := m.Predict([]string{"charged", "crash", "error"})
+		assert.NoError(t, err, name)
+		assert.Equal(t, []int{billing, bug}, labels, name)
+	}
+
+	// fuzzy matching reaches every label
+	typos := []string{"pasword", "resett", "lockd"}
+	labels, err := ticketClassifier(t).Predict(typos)
+	assert.NoError(t, err)
+	assert.NotContains(t, labels, login)
+	labels, err = ticketClassifier(t, WithFuzzyMatching(1, 0.5)).Predict(typos)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{login}, labels)
+
+	for _, opt := range []Option{WithDecay(time.Hour), WithAutoDecay(time.Hour), WithWindow(3, time.Hour)} {
+		_, err := NewMultiLabelClassifier(3, 1, opt)
+		assert.Equal(t, ErrUnsupportedOption, err)
+	}
+}
+
+func TestMultiLabelSharesVocabulary(t *testing.T) {
+	m := ticketClassifier(t)
+
+	// every label sees the whole vocabulary even though each word was stored once
+	for i := 0; i < m.Labels(); i++ {
+		c, err := m.Label(i)
+		assert.NoError(t, err)
+		assert.Equal(t, 16, c.(Inspector).Stats().UniqueWords)
+	}
+	assert.Equal(t, 16, m.(*multiLabel).Tree.UniqueWords())
+
+	c, err := m.Label(bug)
+	assert.NoError(t, err)
+	assert.NoError(t, c.LearnPositive([]string{"segfault"}))
+	probs, err := m.Scores([]string{"segfault"})
+	assert.NoError(t, err)
+	assert.True(t, probs[bug] > probs[billing])
+
+	_, err = m.Label(-1)
+	assert.Equal(t, ErrInvalidLabel, err)
+}
+
+func TestMultiLabelEncodeDecode(t *testing.T) {
+	m := ticketClassifier(t)
+	assert.NoError(t, m.SetThreshold(bug, 0.3))
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, gob.NewEncoder(buf).Encode(&m))
+	var decoded MultiLabelClassifier
+	assert.NoError(t, gob.NewDecoder(buf).Decode(&decoded))
+
+	want, err := m.Scores([]string{"charged", "crash"})
+	assert.NoError(t, err)
+	got, err := decoded.Scores([]string{"charged", "crash"})
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, 0.3, decoded.(*multiLabel).Thresholds[bug])
+
+	// the decoded classifier keeps learning into a single shared tree
+	assert.NoError(t, decoded.Learn([]string{"new"}, []int{login}))
+	assert.Equal(t, 17, decoded.(*multiLabel).Tree.UniqueWords())
+}
+
+func TestEvaluateMultiLabel(t *testing.T) {
+	m := ticketClassifier(t)
+
+	e, err := EvaluateMultiLabel(m, []MultiLabelDocument{
+		{Words: []string{"refund", "invoice"}, Labels: []int{billing}},
+		{Words: []string{"charged", "crash", "error"}, Labels: []int{billing, bug}},
+		{Words: []string{"password", "reset"}, Labels: []int{login, bug}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, e.Total)
+	assert.InDelta(t, 2.0/3.0, e.SubsetAccuracy, 1e-9)
+	assert.InDelta(t, 1.0/9.0, e.HammingLoss, 1e-9)
+
+	e, err = EvaluateMultiLabel(m, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, e.Total)
+}
+
+func TestMultiLabelConcurrent(t *testing.T) {
+	m := ticketClassifier(t)
+	c, err := m.Label(bug)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, m.Learn([]string{"more", "words"}, []int{login}))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, c.LearnNegative([]string{"noise"}))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		_, err := m.Predict([]string{"more", "crash"})
+		assert.NoError(t, err)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,87 @@
+package bayesian
+
+import (
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// Option configures a classifier when it is created
+type Option func(*classifier) error
+
+// WithSmoother replaces additive smoothing by the smoothing factor with another smoothing strategy
+func WithSmoother(s Smoother) Option {
+	return func(c *classifier) error {
+		if s == nil {
+			return ErrInvalidSmoother
+		}
+
+		if err := s.Validate(c.Tree.CategoryCount()); err != nil {
+			return err
+		}
+
+		c.Smoother = s
+		return nil
+	}
+}
+
+// WithFeatureHashing stores counts in 2^bits hashed buckets instead of by word, so memory stays the same however many
+// distinct words are learned, in exchange for words that share a bucket sharing their counts. Signed hashing lets
+// those collisions cancel out on average. The vocabulary size used for smoothing is a HyperLogLog estimate, and the
+// hapax and shape unknown policies fall back to smoothing since the words themselves are not kept.
+func WithFeatureHashing(bits uint, signed bool) Option {
+	return func(c *classifier) error {
+		tree, err := radix.NewHashed(c.Tree.CategoryCount(), bits, signed)
+		if err != nil {
+			return err
+		}
+
+		c.Tree = tree
+		return nil
+	}
+}
+
+// WithCountMinSketch stores counts in a Count-Min Sketch per category instead of by word, for streams too large to
+// count exactly. A word's count is never underestimated, and is overestimated by more than epsilon times its
+// category's total with probability at most delta. Like feature hashing, the vocabulary size is a HyperLogLog
+// estimate and the hapax and shape unknown policies fall back to smoothing. Classifiers with the same bounds can be
+// combined with Merge.
+func WithCountMinSketch(epsilon float64, delta float64) Option {
+	return func(c *classifier) error {
+		tree, err := radix.NewSketch(c.Tree.CategoryCount(), epsilon, delta)
+		if err != nil {
+			return err
+		}
+
+		c.Tree = tree
+		return nil
+	}
+}
+
+// WithPersistentTree stores counts in a radix tree that is copied along the path of each word learned instead of
+// changed in place. Scoring reads a snapshot of the tree and never waits for learning, and saving a model while it
+// learns writes a consistent snapshot. In exchange each learned word allocates a copy of its path.
+func WithPersistentTree() Option {
+	return func(c *classifier) error {
+		tree, err := radix.NewPersistent(c.Tree.CategoryCount())
+		if err != nil {
+			return err
+		}
+
+		c.Tree = tree
+		return nil
+	}
+}
+
+// WithArenaTree stores counts in a radix tree laid out in a few large slices instead of a heap object per node. It
+// holds the same counts in less memory, and with millions of words the garbage collector no longer has to trace the
+// tree node by node.
+func WithArenaTree() Option {
+	return func(c *classifier) error {
+		tree, err := radix.NewArena(c.Tree.CategoryCount())
+		if err != nil {
+			return err
+		}
+
+		c.Tree = tree
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package bayesian
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFeatureHashing(t *testing.T) {
+	_, err := NewClassifier(2, 1, WithFeatureHashing(0, true))
+	assert.Equal(t, radix.ErrInvalidBits, err)
+
+	exact := syntheticCorpus(t, 3, 2000, 60)
+	hashedModel := syntheticCorpus(t, 3, 2000, 60, WithFeatureHashing(20, true))
+
+	// with far more buckets than words there are almost no collisions, so the decisions agree
+	agree := 0
+	docs := syntheticDocs(50, 2000)
+	for _, doc := range docs {
+		want, err := exact.Score(doc)
+		assert.NoError(t, err)
+		got, err := hashedModel.Score(doc)
+		assert.NoError(t, err)
+		if want.Best == got.Best {
+			agree++
+		}
+	}
+	assert.True(t, agree >= 48, "%d of %d agree", agree, len(docs))
+
+	// the hashed tree is saved with the model
+	m := &Model{Labels: []string{"a", "b", "c"}, Classifier: hashedModel}
+	buf := new(bytes.Buffer)
+	assert.NoError(t, m.Save(buf))
+	loaded, err := LoadModel(buf)
+	assert.NoError(t, err)
+
+	want, err := hashedModel.Score(docs[0])
+	assert.NoError(t, err)
+	got, err := loaded.Classifier.Score(docs[0])
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestWithCountMinSketch(t *testing.T) {
+	_, err := NewClassifier(2, 1, WithCountMinSketch(0, 0.01))
+	assert.Equal(t, radix.ErrInvalidErrorBounds, err)
+
+	exact := syntheticCorpus(t, 3, 2000, 60)
+	sketched := syntheticCorpus(t, 3, 2000, 60, WithCountMinSketch(0.0001, 0.01))
+
+	agree := 0
+	docs := syntheticDocs(50, 2000)
+	for _, doc := range docs {
+		want, err := exact.Score(doc)
+		assert.NoError(t, err)
+		got, err := sketched.Score(doc)
+		assert.NoError(t, err)
+		if want.Best == got.Best {
+			agree++
+		}
+	}
+	assert.True(t, agree >= 48, "%d of %d agree", agree, len(docs))
+}
+
+func TestWithPersistentTree(t *testing.T) {
+	for _, policy := range []UnknownPolicy{UnknownSmooth, UnknownHapax, UnknownShape} {
+		exact := syntheticCorpus(t, 3, 500, 100, WithUnknownPolicy(policy))
+		persistent := syntheticCorpus(t, 3, 500, 100, WithUnknownPolicy(policy), WithPersistentTree())
+
+		// the same counts score the same
+		for _, doc := range syntheticDocs(20, 600) {
+			want, err := exact.Score(doc)
+			assert.NoError(t, err)
+			got, err := persistent.Score(doc)
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+	}
+
+	c := syntheticCorpus(t, 2, 200, 20, WithUnknownPolicy(UnknownHapax), WithPersistentTree())
+	docs := syntheticDocs(200, 300)
+
+	// scoring and saving carry on while learning
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, doc := range docs {
+			assert.NoError(t, c.Learn(doc, Negative))
+		}
+	}()
+
+	for _, doc := range docs {
+		_, err := c.Score(doc)
+		assert.NoError(t, err)
+	}
+
+	m := &Model{Labels: []string{"ham", "spam"}, Classifier: c}
+	buf := new(bytes.Buffer)
+	assert.NoError(t, m.Save(buf))
+	<-done
+
+	loaded, err := LoadModel(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, loaded.Classifier.Learn([]string{"loaded"}, Positive))
+	result, err := loaded.Classifier.Score([]string{"loaded"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Known)
+}
+
+func TestWithArenaTree(t *testing.T) {
+	for _, policy := range []UnknownPolicy{UnknownSmooth, UnknownHapax, UnknownShape} {
+		exact := syntheticCorpus(t, 3, 500, 100, WithUnknownPolicy(policy))
+		arena := syntheticCorpus(t, 3, 500, 100, WithUnknownPolicy(policy), WithArenaTree())
+
+		for _, doc := range syntheticDocs(20, 600) {
+			want, err := exact.Score(doc)
+			assert.NoError(t, err)
+			got, err := arena.Score(doc)
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+
+		// it compiles like the pointer tree, since it walks the same words, and also keeps the IDs of the words
+		want, err := Compile(exact)
+		assert.NoError(t, err)
+		got, err := Compile(arena)
+		assert.NoError(t, err)
+		assert.Len(t, got.(*compiled).IDs, got.(*compiled).Table.Len())
+		got.(*compiled).IDs, got.(*compiled).Rows = nil, nil
+		assert.Equal(t, want, got)
+	}
+}
@@ -0,0 +1,178 @@
+package bayesian
+
+import (
+	"math"
+	"math/big"
+	"sort"
+)
+
+// ScoreResult is the outcome of scoring a document
+type ScoreResult struct {
+	// Scores is the posterior probability of each category, the same values as Posteriors
+	Scores []*big.Float
+	// Posteriors is the posterior probability of each category
+	Posteriors []float64
+	// LogLikelihoods is the natural log of the probability of the document's words given each category
+	LogLikelihoods []float64
+	// Best is the category with the highest posterior, and Strict reports whether no other category tied with it
+	Best   int
+	Strict bool
+	// Second is the runner up, it is the same as Best for a classifier with a single category
+	Second int
+	// Margin is the difference between the posteriors of Best and Second
+	Margin float64
+	// Entropy is the entropy of the posteriors divided by its maximum, 0 when one category is certain and 1 when
+	// every category is equally likely
+	Entropy float64
+	// Ranked lists every category from most to least likely
+	Ranked []RankedCategory
+	// Known and Unknown count the words of the document the classifier had and had not learned
+	Known   int
+	Unknown int
+}
+
+// RankedCategory is a category along with its posterior
+type RankedCategory struct {
+	Category  int
+	Posterior float64
+}
+
+// Top returns the k most likely categories
+func (r *ScoreResult) Top(k int) []RankedCategory {
+	if k > len(r.Ranked) {
+		k = len(r.Ranked)
+	}
+	if k < 0 {
+		k = 0
+	}
+	return r.Ranked[:k]
+}
+
+// Confident reports whether the best category leads the runner up by at least margin, which is what routing needs
+// to decide between acting on a label and sending the document to a human
+func (r *ScoreResult) Confident(margin float64) bool {
+	return r.Strict && r.Margin >= margin
+}
+
+// wordModel is the read only view of a model that scoring works against, every classifier implementation provides one
+type wordModel interface {
+	// categoryCount is the number of categories of the model
+	categoryCount() int
+	// logPriors returns the natural log of the prior of each category
+	logPriors() []float64
+	// wordLogProbs fills dst with the natural log of P(word|category), it reports whether the word is known and
+	// whether it contributes to the score at all
+	wordLogProbs(word string, dst []float64) (known bool, use bool, err error)
+}
+
+// accumulator sums the log likelihoods of a document word by word
+type accumulator struct {
+	logLikelihoods []float64
+	known          int
+	unknown        int
+	buf            []float64
+}
+
+func newAccumulator(categories int) *accumulator {
+	return &accumulator{logLikelihoods: make([]float64, categories), buf: make([]float64, categories)}
+}
+
+// add scores one more word of the document
+func (a *accumulator) add(m wordModel, word string) error {
+	known, use, err := m.wordLogProbs(word, a.buf)
+	if err != nil {
+		return err
+	}
+
+	if known {
+		a.known++
+	} else {
+		a.unknown++
+	}
+
+	if use {
+		for i, logProb := range a.buf {
+			a.logLikelihoods[i] += logProb
+		}
+	}
+	return nil
+}
+
+// result turns the accumulated log likelihoods into posteriors using the priors
+func (a *accumulator) result(logPriors []float64) (*ScoreResult, error) {
+	n := len(a.logLikelihoods)
+	joint := make([]float64, n)
+	max := math.Inf(-1)
+	for i := range joint {
+		joint[i] = logPriors[i] + a.logLikelihoods[i]
+		if math.IsNaN(joint[i]) {
+			return nil, ErrDegenerateModel
+		}
+		if joint[i] > max {
+			max = joint[i]
+		}
+	}
+
+	// if every category gives the document zero probability we would divide 0 by 0
+	if math.IsInf(max, -1) {
+		return nil, ErrNaN
+	}
+
+	// normalize in log space so that long documents do not underflow
+	posteriors := make([]float64, n)
+	sum := 0.0
+	for i := range joint {
+		posteriors[i] = math.Exp(joint[i] - max)
+		sum += posteriors[i]
+	}
+
+	r := &ScoreResult{
+		Scores:         make([]*big.Float, n),
+		Posteriors:     posteriors,
+		LogLikelihoods: append([]float64(nil), a.logLikelihoods...),
+		Ranked:         make([]RankedCategory, n),
+		Known:          a.known,
+		Unknown:        a.unknown,
+	}
+
+	entropy := 0.0
+	for i := range posteriors {
+		posteriors[i] /= sum
+		r.Scores[i] = big.NewFloat(posteriors[i])
+		r.Ranked[i] = RankedCategory{Category: i, Posterior: posteriors[i]}
+		if posteriors[i] > 0 {
+			entropy -= posteriors[i] * math.Log(posteriors[i])
+		}
+	}
+
+	if n > 1 {
+		r.Entropy = entropy / math.Log(float64(n))
+	}
+
+	// the joint log probabilities decide the ranking, so exact ties are reported even when the posteriors round
+	sort.SliceStable(r.Ranked, func(i, j int) bool {
+		return joint[r.Ranked[i].Category] > joint[r.Ranked[j].Category]
+	})
+
+	r.Best = r.Ranked[0].Category
+	r.Second = r.Best
+	r.Strict = true
+	if n > 1 {
+		r.Second = r.Ranked[1].Category
+		r.Margin = posteriors[r.Best] - posteriors[r.Second]
+		r.Strict = joint[r.Best] != joint[r.Second]
+	}
+
+	return r, nil
+}
+
+// score runs a whole document through a model
+func score(m wordModel, doc []string) (*ScoreResult, error) {
+	a := newAccumulator(m.categoryCount())
+	for _, word := range doc {
+		if err := a.add(m, word); err != nil {
+			return nil, err
+		}
+	}
+	return a.result(m.logPriors())
+}
@@ -0,0 +1,91 @@
+package bayesian
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var rankedDocs = []Document{
+	{Words: []string{"refund", "refund", "charge", "invoice"}, Category: 0},
+	{Words: []string{"password", "login", "reset", "invoice"}, Category: 1},
+	{Words: []string{"crash", "error", "login", "reset"}, Category: 2},
+}
+
+func TestScoreResult(t *testing.T) {
+	c := trainedClassifier(t, 3, rankedDocs)
+
+	r, err := c.Score([]string{"refund", "invoice", "mystery"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.Best)
+	assert.Equal(t, 1, r.Second)
+	assert.True(t, r.Strict)
+	assert.Equal(t, 2, r.Known)
+	assert.Equal(t, 1, r.Unknown)
+
+	sum := 0.0
+	for i, p := range r.Posteriors {
+		sum += p
+		f, _ := r.Scores[i].Float64()
+		assert.Equal(t, p, f)
+	}
+	assert.InDelta(t, 1, sum, 1e-12)
+	assert.InDelta(t, r.Posteriors[0]-r.Posteriors[1], r.Margin, 1e-12)
+	assert.True(t, r.Entropy > 0 && r.Entropy < 1)
+
+	// the log likelihoods do not include the priors, which are equal here
+	assert.Len(t, r.LogLikelihoods, 3)
+	assert.InDelta(t, r.Posteriors[0]/r.Posteriors[1], math.Exp(r.LogLikelihoods[0]-r.LogLikelihoods[1]), 1e-9)
+
+	assert.Equal(t, []int{0, 1, 2}, categoriesOf(r.Ranked))
+	assert.Equal(t, []int{0, 1}, categoriesOf(r.Top(2)))
+	assert.Len(t, r.Top(10), 3)
+	assert.Empty(t, r.Top(-1))
+
+	assert.True(t, r.Confident(0.1))
+	assert.False(t, r.Confident(1))
+}
+
+func TestScoreResultTies(t *testing.T) {
+	c := trainedClassifier(t, 3, rankedDocs)
+
+	r, err := c.Score([]string{"login", "reset"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r.Best)
+	assert.Equal(t, 2, r.Second)
+	assert.False(t, r.Strict)
+	assert.Equal(t, 0.0, r.Margin)
+	assert.False(t, r.Confident(0))
+
+	// with no evidence every category is equally likely
+	r, err = c.Score(nil)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1, r.Entropy, 1e-12)
+	assert.Equal(t, 0, r.Best)
+}
+
+func TestScoreLongDocument(t *testing.T) {
+	c := trainedClassifier(t, 3, rankedDocs)
+
+	// thousands of words would underflow a float64 product, log space keeps the posteriors meaningful
+	doc := make([]string, 5000)
+	for i := range doc {
+		doc[i] = "refund"
+	}
+	doc[0] = "password"
+
+	r, err := c.Score(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.Best)
+	assert.Equal(t, 1.0, r.Posteriors[0])
+	assert.True(t, r.LogLikelihoods[0] < -1000)
+}
+
+func categoriesOf(ranked []RankedCategory) []int {
+	var categories []int
+	for _, r := range ranked {
+		categories = append(categories, r.Category)
+	}
+	return categories
+}
@@ -0,0 +1,138 @@
+package bayesian
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// ErrInvalidConfidence is an error we throw when the confidence of an early stop is not strictly between 0.5 and 1
+var ErrInvalidConfidence = errors.New("bayesian: invalid confidence")
+
+// Scorer scores a document a word at a time, so that a stream can be scored as it arrives instead of being held in
+// memory. It keeps the running log likelihood of each category, and with StopAt it stops as soon as the best
+// category is certain enough. A Scorer is not safe for concurrent use.
+type Scorer struct {
+	model  wordModel
+	priors []float64
+	// lock is the read lock of a classifier that can learn, it is held while each word is looked up
+	lock sync.Locker
+	acc  *accumulator
+	// bound is the log of the posterior odds of the best category over the runner up at which scoring stops, 0 never
+	// stops
+	bound   float64
+	stopped bool
+	err     error
+}
+
+// newScorer creates a scorer over a model, err is returned by every call when the model cannot score
+func newScorer(m wordModel, lock sync.Locker, err error) *Scorer {
+	s := &Scorer{model: m, lock: lock, err: err}
+	if err == nil {
+		s.priors = m.logPriors()
+		s.acc = newAccumulator(m.categoryCount())
+	}
+	return s
+}
+
+// NewScorer creates a scorer over the classifier. The totals of the classifier are taken now, while the counts of
+// each word are read as it is added, so words learned while the scorer is in use count with the old totals. A tree that
+// can be snapshotted is read as it was when the scorer was created.
+func (c *classifier) NewScorer() *Scorer {
+	lock := c.lock()
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if c.Tree.UniqueWords() == 0 {
+		return newScorer(nil, nil, ErrUntrained)
+	}
+
+	// the view of a tree that can be snapshotted reads a snapshot, which needs no lock
+	if _, ok := c.Tree.(radix.Snapshotter); ok {
+		return newScorer(c.view(), nil, nil)
+	}
+	return newScorer(c.view(), lock.RLocker(), nil)
+}
+
+// NewScorer creates a scorer over the compiled classifier
+func (m *compiled) NewScorer() *Scorer {
+	return newScorer(m, nil, nil)
+}
+
+// NewScorer creates a scorer over the mapped classifier, it must not be used after the classifier is closed
+func (m *mapped) NewScorer() *Scorer {
+	return newScorer(m, nil, nil)
+}
+
+// StopAt makes the scorer stop once the posterior odds of the best category over the runner up reach
+// confidence/(1-confidence). This is Wald's sequential probability ratio test between the two leading categories
+// with both error rates set to 1-confidence.
+func (s *Scorer) StopAt(confidence float64) error {
+	if !(confidence > 0.5 && confidence < 1) {
+		return ErrInvalidConfidence
+	}
+
+	s.bound = math.Log(confidence / (1 - confidence))
+	return nil
+}
+
+// Add scores one more word and reports whether the scorer has stopped, words added after it stopped are ignored
+func (s *Scorer) Add(word string) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+
+	if s.stopped {
+		return true, nil
+	}
+
+	if s.lock != nil {
+		s.lock.Lock()
+	}
+	err := s.acc.add(s.model, word)
+	if s.lock != nil {
+		s.lock.Unlock()
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	s.stopped = s.bound > 0 && s.odds() >= s.bound
+	return s.stopped, nil
+}
+
+// odds is the log of the posterior odds of the best category over the runner up
+func (s *Scorer) odds() float64 {
+	best, second := math.Inf(-1), math.Inf(-1)
+	for i, logLikelihood := range s.acc.logLikelihoods {
+		joint := s.priors[i] + logLikelihood
+		switch {
+		case joint > best:
+			best, second = joint, best
+		case joint > second:
+			second = joint
+		}
+	}
+
+	// nothing is certain of a document every category rules out
+	if math.IsInf(best, -1) {
+		return 0
+	}
+	return best - second
+}
+
+// Stopped reports whether the scorer reached its confidence
+func (s *Scorer) Stopped() bool {
+	return s.stopped
+}
+
+// Result scores the words added so far, it can be called at any point and scoring can carry on afterwards
+func (s *Scorer) Result() (*ScoreResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.acc.result(s.priors)
+}
@@ -0,0 +1,133 @@
+package bayesian
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScorerMatchesScore(t *testing.T) {
+	c := syntheticCorpus(t, 3, 200, 300)
+	model, err := Compile(c)
+	assert.NoError(t, err)
+
+	for _, cl := range []Classifier{c, model} {
+		for _, doc := range syntheticDocs(20, 250) {
+			want, err := cl.Score(doc)
+			assert.NoError(t, err)
+
+			s := cl.NewScorer()
+			for _, word := range doc {
+				stopped, err := s.Add(word)
+				assert.NoError(t, err)
+				assert.False(t, stopped)
+			}
+
+			got, err := s.Result()
+			assert.NoError(t, err)
+			assert.InDeltaSlice(t, want.Posteriors, got.Posteriors, 1e-9)
+			assert.InDeltaSlice(t, want.LogLikelihoods, got.LogLikelihoods, 1e-9)
+			assert.Equal(t, want.Best, got.Best)
+			assert.Equal(t, want.Known, got.Known)
+			assert.Equal(t, want.Unknown, got.Unknown)
+		}
+	}
+}
+
+func TestScorerStopAt(t *testing.T) {
+	c, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, c.Learn([]string{"prize", "money", "winner"}, Positive))
+		assert.NoError(t, c.Learn([]string{"meeting", "agenda", "notes"}, Negative))
+	}
+
+	s := c.NewScorer()
+	assert.Equal(t, ErrInvalidConfidence, s.StopAt(0.5))
+	assert.Equal(t, ErrInvalidConfidence, s.StopAt(1))
+	assert.NoError(t, s.StopAt(0.999))
+
+	added := 0
+	for i := 0; i < 1000; i++ {
+		added++
+		stopped, err := s.Add("prize")
+		assert.NoError(t, err)
+		if stopped {
+			break
+		}
+	}
+	assert.True(t, s.Stopped())
+	assert.True(t, added > 1 && added < 1000)
+
+	// words after the stop are ignored
+	stopped, err := s.Add("meeting")
+	assert.NoError(t, err)
+	assert.True(t, stopped)
+
+	result, err := s.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, Positive, result.Best)
+	assert.Equal(t, added, result.Known)
+	assert.True(t, result.Posteriors[Positive] >= 0.999)
+
+	// a lower confidence stops sooner
+	s = c.NewScorer()
+	assert.NoError(t, s.StopAt(0.9))
+	sooner := 0
+	for stopped := false; !stopped; {
+		sooner++
+		stopped, err = s.Add("prize")
+		assert.NoError(t, err)
+	}
+	assert.True(t, sooner < added)
+}
+
+func TestScorerUntrained(t *testing.T) {
+	c, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+
+	s := c.NewScorer()
+	_, err = s.Add("prize")
+	assert.Equal(t, ErrUntrained, err)
+	_, err = s.Result()
+	assert.Equal(t, ErrUntrained, err)
+}
+
+func TestScorerConcurrentLearn(t *testing.T) {
+	c := syntheticCorpus(t, 2, 100, 50)
+	s := c.NewScorer()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, doc := range syntheticDocs(50, 100) {
+			assert.NoError(t, c.Learn(doc, Positive))
+		}
+	}()
+
+	for _, doc := range syntheticDocs(50, 100) {
+		for _, word := range doc {
+			_, err := s.Add(word)
+			assert.NoError(t, err)
+		}
+	}
+	<-done
+
+	_, err := s.Result()
+	assert.NoError(t, err)
+}
+
+func BenchmarkScorer(b *testing.B) {
+	c := syntheticCorpus(b, 4, 5000, 2000)
+	docs := syntheticDocs(100, 6000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := c.NewScorer()
+		for _, word := range docs[i%len(docs)] {
+			if _, err := s.Add(word); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Package server exposes a bayesian model as a JSON HTTP API
+//
+// The server answers on four endpoints:
+//
+//	POST /classify  {"text": "..."} or {"tokens": [...]}, returns the label, strictness and per label scores
+//	POST /learn     {"text": "...", "label": "..."}, teaches the model a document unless learning is disabled
+//	GET  /model     returns the labels, category totals, vocabulary size and smoothing factor of the model
+//	GET  /healthz   returns 200 once a model is loaded
+//
+// A server opened from a file can reload it with Reload or Watch, the new model is swapped in atomically so requests
+// that are in flight finish against the model they started with and no request is dropped.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/LegoRemix/bayesian"
+)
+
+// Options controls the behavior of a Server
+type Options struct {
+	// Tokenizer splits the text of a request into words, bayesian.Tokenize is used when it is nil
+	Tokenizer func(string) []string
+	// DisableLearn rejects requests to /learn with 403 Forbidden
+	DisableLearn bool
+	// MaxBodyBytes limits the size of a request body, DefaultMaxBodyBytes is used when it is zero
+	MaxBodyBytes int64
+}
+
+// DefaultMaxBodyBytes is the size limit of a request body when Options.MaxBodyBytes is zero
+const DefaultMaxBodyBytes = 1 << 20
+
+// Server is an http.Handler serving a single model
+type Server struct {
+	opts    Options
+	path    string
+	current atomic.Value // holds a *state
+	reload  sync.Mutex   // serializes reloads so a slow one cannot overwrite a newer model
+	mux     *http.ServeMux
+}
+
+// state is a loaded model, the classifiers of the bayesian package do their own locking so requests share it freely
+type state struct {
+	model    *bayesian.Model
+	modTime  time.Time
+	loadedAt time.Time
+}
+
+// ErrNoPath is an error we return when asked to reload a server that was not opened from a file
+var ErrNoPath = errors.New("server: model was not loaded from a file")
+
+// New creates a server for a model held in memory
+func New(model *bayesian.Model, opts Options) *Server {
+	s := &Server{opts: opts}
+	if s.opts.Tokenizer == nil {
+		s.opts.Tokenizer = bayesian.Tokenize
+	}
+	if s.opts.MaxBodyBytes == 0 {
+		s.opts.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	s.current.Store(&state{model: model, loadedAt: time.Now()})
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/classify", s.handleClassify)
+	s.mux.HandleFunc("/learn", s.handleLearn)
+	s.mux.HandleFunc("/model", s.handleModel)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	return s
+}
+
+// Open creates a server for the model saved at path, which can later be reloaded
+func Open(path string, opts Options) (*Server, error) {
+	st, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := New(st.model, opts)
+	s.path = path
+	s.current.Store(st)
+	return s, nil
+}
+
+// load reads a model and the modification time it had when we read it
+func load(path string) (*state, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := bayesian.LoadModelFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state{model: model, modTime: info.ModTime(), loadedAt: time.Now()}, nil
+}
+
+// Model returns the model currently being served
+func (s *Server) Model() *bayesian.Model {
+	return s.state().model
+}
+
+func (s *Server) state() *state {
+	return s.current.Load().(*state)
+}
+
+// Reload reads the model file again and atomically swaps it in, on error the current model keeps being served.
+// Anything learned through /learn since the last load is discarded.
+func (s *Server) Reload() error {
+	if s.path == "" {
+		return ErrNoPath
+	}
+
+	s.reload.Lock()
+	defer s.reload.Unlock()
+
+	st, err := load(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.current.Store(st)
+	return nil
+}
+
+// Watch polls the model file every interval and reloads it when its modification time changes, until ctx is done.
+// Errors from reloading are passed to onError, which may be nil.
+func (s *Server) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(s.path)
+		if err == nil && info.ModTime().Equal(s.state().modTime) {
+			continue
+		}
+
+		if err == nil {
+			err = s.Reload()
+		}
+
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// ServeHTTP routes a request to the endpoint handling it
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// document is the body of a request to /classify or /learn
+type document struct {
+	Text   string   `json:"text"`
+	Tokens []string `json:"tokens"`
+	Label  string   `json:"label,omitempty"`
+}
+
+// words returns the tokens of a document, tokenizing the text if no tokens were sent
+func (s *Server) words(doc *document) []string {
+	if doc.Tokens != nil {
+		return doc.Tokens
+	}
+	return s.opts.Tokenizer(doc.Text)
+}
+
+// Classification is the response to /classify
+type Classification struct {
+	Label    string             `json:"label"`
+	Category int                `json:"category"`
+	Strict   bool               `json:"strict"`
+	Scores   map[string]float64 `json:"scores"`
+	Second   string             `json:"second"`
+	Margin   float64            `json:"margin"`
+	Entropy  float64            `json:"entropy"`
+	Known    int                `json:"known_tokens"`
+	Unknown  int                `json:"unknown_tokens"`
+}
+
+func (s *Server) handleClassify(w http.ResponseWriter, r *http.Request) {
+	var doc document
+	if !s.decode(w, r, &doc) {
+		return
+	}
+
+	st := s.state()
+	scored, err := st.model.Classifier.Score(s.words(&doc))
+	if err != nil {
+		fail(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	result := Classification{
+		Label:    st.model.Label(scored.Best),
+		Category: scored.Best,
+		Strict:   scored.Strict,
+		Scores:   make(map[string]float64),
+		Second:   st.model.Label(scored.Second),
+		Margin:   scored.Margin,
+		Entropy:  scored.Entropy,
+		Known:    scored.Known,
+		Unknown:  scored.Unknown,
+	}
+	for i, posterior := range scored.Posteriors {
+		result.Scores[st.model.Label(i)] = posterior
+	}
+
+	reply(w, http.StatusOK, result)
+}
+
+func (s *Server) handleLearn(w http.ResponseWriter, r *http.Request) {
+	if s.opts.DisableLearn {
+		fail(w, http.StatusForbidden, "learning is disabled")
+		return
+	}
+
+	var doc document
+	if !s.decode(w, r, &doc) {
+		return
+	}
+
+	st := s.state()
+	category, ok := st.model.Category(doc.Label)
+	if !ok {
+		fail(w, http.StatusBadRequest, "unknown label "+doc.Label)
+		return
+	}
+
+	if err := st.model.Classifier.Learn(s.words(&doc), category); err != nil {
+		fail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ModelInfo is the response to /model
+type ModelInfo struct {
+	Labels          []string       `json:"labels"`
+	CategoryTotals  map[string]int `json:"category_totals,omitempty"`
+	UniqueWords     int            `json:"unique_words"`
+	SmoothingFactor float64        `json:"smoothing_factor"`
+	Smoother        string         `json:"smoother,omitempty"`
+	LoadedAt        time.Time      `json:"loaded_at"`
+	LearnEnabled    bool           `json:"learn_enabled"`
+}
+
+func (s *Server) handleModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		fail(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+
+	st := s.state()
+	info := ModelInfo{Labels: st.model.Labels, LoadedAt: st.loadedAt, LearnEnabled: !s.opts.DisableLearn}
+	if inspector, ok := st.model.Classifier.(bayesian.Inspector); ok {
+		stats := inspector.Stats()
+		info.UniqueWords = stats.UniqueWords
+		info.SmoothingFactor = stats.SmoothingFactor
+		info.Smoother = fmt.Sprint(stats.Smoother)
+		info.CategoryTotals = make(map[string]int)
+		for i, total := range stats.CategoryTotals {
+			info.CategoryTotals[st.model.Label(i)] = total
+		}
+	}
+
+	reply(w, http.StatusOK, info)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	reply(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// decode reads a JSON request body of at most MaxBodyBytes, replying with an error and returning false when it cannot
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		fail(w, http.StatusMethodNotAllowed, "use POST")
+		return false
+	}
+
+	body := http.MaxBytesReader(w, r.Body, s.opts.MaxBodyBytes)
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			fail(w, http.StatusRequestEntityTooLarge, err.Error())
+			return false
+		}
+		fail(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func reply(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func fail(w http.ResponseWriter, status int, message string) {
+	reply(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LegoRemix/bayesian"
+	"github.com/stretchr/testify/assert"
+)
+
+func testModel(t *testing.T, spamWord string) *bayesian.Model {
+	m, err := bayesian.NewModel([]string{"ham", "spam"}, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, m.Classifier.Learn([]string{"meeting", "report", "lunch"}, 0))
+	assert.NoError(t, m.Classifier.Learn([]string{spamWord, "prize", "winner"}, 1))
+	return m
+}
+
+func post(t *testing.T, h http.Handler, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	return rec
+}
+
+func TestClassify(t *testing.T) {
+	s := New(testModel(t, "lottery"), Options{})
+
+	rec := post(t, s, "/classify", `{"text": "You are a lottery WINNER"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var result Classification
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, "spam", result.Label)
+	assert.Equal(t, "ham", result.Second)
+	assert.True(t, result.Margin > 0)
+	assert.Equal(t, 2, result.Known)
+	assert.Equal(t, 3, result.Unknown)
+	assert.Equal(t, 1, result.Category)
+	assert.True(t, result.Strict)
+	assert.InDelta(t, 1, result.Scores["ham"]+result.Scores["spam"], 1e-9)
+
+	rec = post(t, s, "/classify", `{"tokens": ["meeting"]}`)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, "ham", result.Label)
+
+	assert.Equal(t, http.StatusBadRequest, post(t, s, "/classify", `{`).Code)
+
+	untrained, err := bayesian.NewModel([]string{"ham", "spam"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(t, New(untrained, Options{}), "/classify", `{"text": "x"}`).Code)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/classify", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestLearn(t *testing.T) {
+	s := New(testModel(t, "lottery"), Options{})
+
+	assert.Equal(t, http.StatusNoContent, post(t, s, "/learn", `{"text": "casino casino casino", "label": "spam"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(t, s, "/learn", `{"text": "casino", "label": "eggs"}`).Code)
+
+	var result Classification
+	assert.NoError(t, json.Unmarshal(post(t, s, "/classify", `{"text": "casino"}`).Body.Bytes(), &result))
+	assert.Equal(t, "spam", result.Label)
+
+	disabled := New(testModel(t, "lottery"), Options{DisableLearn: true})
+	assert.Equal(t, http.StatusForbidden, post(t, disabled, "/learn", `{"text": "casino", "label": "spam"}`).Code)
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	s := New(testModel(t, "lottery"), Options{MaxBodyBytes: 64})
+
+	assert.Equal(t, http.StatusOK, post(t, s, "/classify", `{"text": "lottery prize"}`).Code)
+	long := `{"text": "` + strings.Repeat("lottery ", 20) + `"}`
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(t, s, "/classify", long).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(t, s, "/learn", long).Code)
+
+	// the default limit takes a megabyte
+	s = New(testModel(t, "lottery"), Options{})
+	assert.Equal(t, http.StatusOK, post(t, s, "/classify", long).Code)
+	huge := `{"text": "` + strings.Repeat("lottery ", DefaultMaxBodyBytes/8) + `"}`
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(t, s, "/classify", huge).Code)
+}
+
+func TestModelAndHealth(t *testing.T) {
+	s := New(testModel(t, "lottery"), Options{})
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/model", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var info ModelInfo
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.Equal(t, []string{"ham", "spam"}, info.Labels)
+	assert.Equal(t, 6, info.UniqueWords)
+	assert.Equal(t, map[string]int{"ham": 3, "spam": 3}, info.CategoryTotals)
+	assert.True(t, info.LearnEnabled)
+	assert.Equal(t, "additive(alpha=1)", info.Smoother)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gob")
+	assert.NoError(t, testModel(t, "lottery").SaveFile(path))
+
+	s, err := Open(path, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, ErrNoPath, New(testModel(t, "lottery"), Options{}).Reload())
+
+	classify := func(text string) string {
+		var result Classification
+		assert.NoError(t, json.Unmarshal(post(t, s, "/classify", `{"text": "`+text+`"}`).Body.Bytes(), &result))
+		return result.Label
+	}
+	assert.Equal(t, "ham", classify("casino meeting"))
+
+	// keep classifying while the model is swapped out underneath us
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for ctx.Err() == nil {
+			rec := post(t, s, "/classify", `{"text": "casino"}`)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	}()
+
+	assert.NoError(t, testModel(t, "casino").SaveFile(path))
+	assert.NoError(t, s.Reload())
+	assert.Equal(t, "spam", classify("casino"))
+
+	// a broken file leaves the current model in place
+	assert.NoError(t, os.WriteFile(path, []byte("garbage"), 0o644))
+	assert.Error(t, s.Reload())
+	assert.Equal(t, "spam", classify("casino"))
+
+	cancel()
+	wg.Wait()
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gob")
+	assert.NoError(t, testModel(t, "lottery").SaveFile(path))
+
+	s, err := Open(path, Options{})
+	assert.NoError(t, err)
+	first := s.Model()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Watch(ctx, 5*time.Millisecond, nil)
+
+	assert.NoError(t, testModel(t, "casino").SaveFile(path))
+	future := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(path, future, future))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Model() == first && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.True(t, s.Model() != first)
+}
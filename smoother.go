@@ -0,0 +1,264 @@
+package bayesian
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// Smoother estimates the probability of a word given a category from how often the word was seen in it
+type Smoother interface {
+	// Prob returns P(word|category) for a word seen count times in the category
+	Prob(count float64, category int, s *SmoothingContext) float64
+	// Validate checks the smoother's parameters against the number of categories of the classifier
+	Validate(categories int) error
+}
+
+// SmoothingContext is what a Smoother knows about the model besides the count of the word being scored
+type SmoothingContext struct {
+	// Totals is the number of words learned in each category
+	Totals []float64
+	// CategoryWords is the number of distinct words learned in each category
+	CategoryWords []float64
+	// UniqueWords is the size of the vocabulary
+	UniqueWords float64
+	// Background is the probability of the word across all categories, add one smoothed so it is never 0
+	Background float64
+
+	// at is the time decayed counts are read at
+	at time.Time
+	// tree is the tree counts are read from, a snapshot of the classifier's tree when it can take one
+	tree radix.Tree
+}
+
+// ErrInvalidSmoother is an error we throw when the parameters of a smoother are out of range
+var ErrInvalidSmoother = errors.New("bayesian: invalid smoother")
+
+func init() {
+	gob.Register(Additive{})
+	gob.Register(Lidstone{})
+	gob.Register(JelinekMercer{})
+	gob.Register(Dirichlet{})
+	gob.Register(AbsoluteDiscount{})
+	gob.Register(WittenBell{})
+}
+
+// Additive is Laplace (Alpha 1) or Lidstone smoothing with one pseudo count shared by all categories, it is what a
+// classifier uses when no other smoother is given
+type Additive struct {
+	Alpha float64
+}
+
+// Prob computes (count + alpha) / (total + alpha * V)
+func (a Additive) Prob(count float64, category int, s *SmoothingContext) float64 {
+	return (count + a.Alpha) / (s.Totals[category] + a.Alpha*s.UniqueWords)
+}
+
+// Validate checks that the pseudo count is not negative
+func (a Additive) Validate(categories int) error {
+	if a.Alpha < 0 {
+		return ErrInvalidSmoothingFactor
+	}
+	return nil
+}
+
+func (a Additive) String() string {
+	return fmt.Sprintf("additive(alpha=%g)", a.Alpha)
+}
+
+// Lidstone is additive smoothing with a separate pseudo count for every category, so short message classes can be
+// smoothed more heavily than long document ones
+type Lidstone struct {
+	Alphas []float64
+}
+
+// Prob computes (count + alpha_c) / (total_c + alpha_c * V)
+func (l Lidstone) Prob(count float64, category int, s *SmoothingContext) float64 {
+	alpha := l.Alphas[category]
+	return (count + alpha) / (s.Totals[category] + alpha*s.UniqueWords)
+}
+
+// Validate checks that there is one non negative pseudo count per category
+func (l Lidstone) Validate(categories int) error {
+	if len(l.Alphas) != categories {
+		return ErrInvalidSmoother
+	}
+
+	for _, alpha := range l.Alphas {
+		if alpha < 0 {
+			return ErrInvalidSmoother
+		}
+	}
+	return nil
+}
+
+func (l Lidstone) String() string {
+	return fmt.Sprintf("lidstone(alphas=%v)", l.Alphas)
+}
+
+// JelinekMercer interpolates the maximum likelihood estimate of a category with the background distribution
+type JelinekMercer struct {
+	// Lambda is the weight of the background distribution
+	Lambda float64
+}
+
+// Prob computes (1 - lambda) * count / total + lambda * P(word)
+func (j JelinekMercer) Prob(count float64, category int, s *SmoothingContext) float64 {
+	ml := 0.0
+	if s.Totals[category] > 0 {
+		ml = count / s.Totals[category]
+	}
+	return (1-j.Lambda)*ml + j.Lambda*s.Background
+}
+
+// Validate checks that lambda is a weight between 0 and 1
+func (j JelinekMercer) Validate(categories int) error {
+	if j.Lambda < 0 || j.Lambda > 1 {
+		return ErrInvalidSmoother
+	}
+	return nil
+}
+
+func (j JelinekMercer) String() string {
+	return fmt.Sprintf("jelinek-mercer(lambda=%g)", j.Lambda)
+}
+
+// Dirichlet uses the background distribution as a Dirichlet prior worth Mu pseudo words, so long categories rely on
+// their own counts and short ones on the background
+type Dirichlet struct {
+	Mu float64
+}
+
+// Prob computes (count + mu * P(word)) / (total + mu)
+func (d Dirichlet) Prob(count float64, category int, s *SmoothingContext) float64 {
+	return (count + d.Mu*s.Background) / (s.Totals[category] + d.Mu)
+}
+
+// Validate checks that the prior has a positive weight
+func (d Dirichlet) Validate(categories int) error {
+	if d.Mu <= 0 {
+		return ErrInvalidSmoother
+	}
+	return nil
+}
+
+func (d Dirichlet) String() string {
+	return fmt.Sprintf("dirichlet(mu=%g)", d.Mu)
+}
+
+// AbsoluteDiscount takes a fixed discount from every seen word and spreads it over the background distribution
+type AbsoluteDiscount struct {
+	Delta float64
+}
+
+// Prob computes (max(count - delta, 0) + delta * T_c * P(word)) / total, where T_c is the number of distinct words
+// seen in the category
+func (a AbsoluteDiscount) Prob(count float64, category int, s *SmoothingContext) float64 {
+	total := s.Totals[category]
+	if total == 0 {
+		return s.Background
+	}
+
+	discounted := count - a.Delta
+	if discounted < 0 {
+		discounted = 0
+	}
+	return (discounted + a.Delta*s.CategoryWords[category]*s.Background) / total
+}
+
+// Validate checks that the discount is between 0 and 1
+func (a AbsoluteDiscount) Validate(categories int) error {
+	if a.Delta <= 0 || a.Delta > 1 {
+		return ErrInvalidSmoother
+	}
+	return nil
+}
+
+func (a AbsoluteDiscount) String() string {
+	return fmt.Sprintf("absolute-discount(delta=%g)", a.Delta)
+}
+
+// WittenBell reserves probability for unseen words in proportion to how many distinct words a category has produced
+type WittenBell struct{}
+
+// Prob computes (count + T_c * P(word)) / (total + T_c), where T_c is the number of distinct words seen in the category
+func (w WittenBell) Prob(count float64, category int, s *SmoothingContext) float64 {
+	types := s.CategoryWords[category]
+	if s.Totals[category]+types == 0 {
+		return s.Background
+	}
+	return (count + types*s.Background) / (s.Totals[category] + types)
+}
+
+// Validate always succeeds, Witten-Bell has no parameters
+func (w WittenBell) Validate(categories int) error {
+	return nil
+}
+
+func (w WittenBell) String() string {
+	return "witten-bell"
+}
+
+// ParseSmoother builds a smoother from a short description such as "additive:1", "lidstone:0.1,1",
+// "jelinek-mercer:0.3", "dirichlet:2000", "absolute-discount:0.7" or "witten-bell"
+func ParseSmoother(spec string) (Smoother, error) {
+	name, arg := spec, ""
+	if colon := strings.IndexByte(spec, ':'); colon >= 0 {
+		name, arg = spec[:colon], spec[colon+1:]
+	}
+
+	var params []float64
+	if arg != "" {
+		for _, field := range strings.Split(arg, ",") {
+			value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("bayesian: invalid smoother %q: %v", spec, err)
+			}
+			params = append(params, value)
+		}
+	}
+
+	one := func() (float64, error) {
+		if len(params) != 1 {
+			return 0, fmt.Errorf("bayesian: smoother %q takes exactly one parameter", name)
+		}
+		return params[0], nil
+	}
+
+	var s Smoother
+	var err error
+	switch name {
+	case "additive", "laplace":
+		var alpha float64
+		alpha, err = one()
+		s = Additive{Alpha: alpha}
+	case "lidstone":
+		s = Lidstone{Alphas: params}
+	case "jelinek-mercer":
+		var lambda float64
+		lambda, err = one()
+		s = JelinekMercer{Lambda: lambda}
+	case "dirichlet":
+		var mu float64
+		mu, err = one()
+		s = Dirichlet{Mu: mu}
+	case "absolute-discount":
+		var delta float64
+		delta, err = one()
+		s = AbsoluteDiscount{Delta: delta}
+	case "witten-bell":
+		s = WittenBell{}
+	default:
+		return nil, fmt.Errorf("bayesian: unknown smoother %q", name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
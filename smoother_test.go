@@ -0,0 +1,116 @@
+package bayesian
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+	"github.com/stretchr/testify/assert"
+)
+
+var smoothers = []Smoother{
+	Additive{Alpha: 1},
+	Lidstone{Alphas: []float64{0.1, 2}},
+	JelinekMercer{Lambda: 0.3},
+	Dirichlet{Mu: 10},
+	AbsoluteDiscount{Delta: 0.7},
+	WittenBell{},
+}
+
+var smoothingDocs = []Document{
+	{Words: []string{"a", "a", "a", "b", "c"}, Category: 0},
+	{Words: []string{"c", "d", "e", "e"}, Category: 1},
+}
+
+func TestSmoothersAreDistributions(t *testing.T) {
+	for _, s := range smoothers {
+		c := trainedClassifier(t, 2, smoothingDocs, WithSmoother(s))
+		ctx := c.smoothingContext()
+
+		// over the whole vocabulary the probabilities of each category sum to one
+		sums := make([]float64, 2)
+		c.Tree.(radix.Walker).Walk(func(word string, counts []int) bool {
+			probs, _, err := c.getCategoryProbs(word, ctx)
+			assert.NoError(t, err)
+			for i, p := range probs {
+				assert.True(t, p > 0, "%v gives %s zero probability", s, word)
+				sums[i] += p
+			}
+			return true
+		})
+
+		for i, sum := range sums {
+			assert.InDelta(t, 1, sum, 1e-9, "%v category %d", s, i)
+		}
+
+		// seen words are more likely than unseen ones
+		seen, _, err := c.getCategoryProbs("a", ctx)
+		assert.NoError(t, err)
+		unseen, _, err := c.getCategoryProbs("zzz", ctx)
+		assert.NoError(t, err)
+		assert.True(t, seen[0] > unseen[0], "%v", s)
+	}
+}
+
+func TestSmoothersClassify(t *testing.T) {
+	for _, s := range smoothers {
+		c := trainedClassifier(t, 2, smoothingDocs, WithSmoother(s))
+		_, idx, _, err := c.Scores([]string{"a", "b"})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, idx, "%v", s)
+
+		_, idx, _, err = c.Scores([]string{"e", "d"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, idx, "%v", s)
+	}
+}
+
+func TestSmootherSavedInModel(t *testing.T) {
+	for _, s := range smoothers {
+		m, err := NewModel([]string{"x", "y"}, 1, WithSmoother(s))
+		assert.NoError(t, err)
+
+		buf := new(bytes.Buffer)
+		assert.NoError(t, gob.NewEncoder(buf).Encode(m))
+		var loaded Model
+		assert.NoError(t, gob.NewDecoder(buf).Decode(&loaded))
+		assert.Equal(t, s, loaded.Classifier.(Inspector).Stats().Smoother)
+	}
+}
+
+func TestSmootherValidation(t *testing.T) {
+	for _, s := range []Smoother{nil, Additive{Alpha: -1}, Lidstone{Alphas: []float64{1}}, Lidstone{Alphas: []float64{1, -1}},
+		JelinekMercer{Lambda: 1.5}, Dirichlet{}, AbsoluteDiscount{Delta: 2}} {
+		_, err := NewClassifier(2, 1, WithSmoother(s))
+		assert.Error(t, err, "%v", s)
+	}
+
+	// a category that has learned nothing falls back on the background instead of failing
+	c, err := NewClassifier(2, 0, WithSmoother(Dirichlet{Mu: 1}))
+	assert.NoError(t, err)
+	assert.NoError(t, c.Learn([]string{"a"}, 0))
+	_, _, _, err = c.Scores([]string{"a"})
+	assert.NoError(t, err)
+}
+
+func TestParseSmoother(t *testing.T) {
+	for spec, want := range map[string]Smoother{
+		"additive:1":            Additive{Alpha: 1},
+		"laplace:1":             Additive{Alpha: 1},
+		"lidstone:0.1,2":        Lidstone{Alphas: []float64{0.1, 2}},
+		"jelinek-mercer:0.3":    JelinekMercer{Lambda: 0.3},
+		"dirichlet:2000":        Dirichlet{Mu: 2000},
+		"absolute-discount:0.7": AbsoluteDiscount{Delta: 0.7},
+		"witten-bell":           WittenBell{},
+	} {
+		s, err := ParseSmoother(spec)
+		assert.NoError(t, err, spec)
+		assert.Equal(t, want, s)
+	}
+
+	for _, spec := range []string{"kneser-ney", "dirichlet", "dirichlet:x", "additive:1,2"} {
+		_, err := ParseSmoother(spec)
+		assert.Error(t, err, spec)
+	}
+}
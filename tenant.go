@@ -0,0 +1,279 @@
+package bayesian
+
+import (
+	"container/list"
+	"encoding/gob"
+	"errors"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrInvalidTenantOptions is an error we throw when the weight, cap or directory of a tenant manager is invalid
+var ErrInvalidTenantOptions = errors.New("bayesian: invalid tenant options")
+
+// ErrUnsupportedClassifier is an error we throw when a classifier cannot be used as the base of a tenant manager
+var ErrUnsupportedClassifier = errors.New("bayesian: unsupported classifier")
+
+// TenantOptions configures a TenantManager
+type TenantOptions struct {
+	// Weight is how much a tenant's own counts are trusted over the base model's, each word's probability is
+	// Weight*P(word|tenant) + (1-Weight)*P(word|base)
+	Weight float64
+	// MaxTenants caps how many tenant models are kept in memory, the least recently used ones are saved and evicted
+	MaxTenants int
+	// Dir is where tenant models are saved, an evicted tenant is loaded from it again the next time it is used
+	Dir string
+}
+
+// TenantManager layers small per tenant models over a shared base classifier, such as one per mailbox over a site
+// wide spam model. A tenant's model only holds what was learned for that tenant, and scores back off to the base
+// model by interpolation, so a tenant that has learned nothing scores exactly like the base model.
+type TenantManager struct {
+	base *classifier
+	opts TenantOptions
+
+	// mu guards the cache of tenants, it is held while tenants are loaded and evicted
+	mu      sync.Mutex
+	tenants map[string]*list.Element
+	lru     *list.List
+}
+
+// tenant is a tenant model in memory, dirty and evicted are guarded by the model's lock
+type tenant struct {
+	id      string
+	c       *classifier
+	dirty   bool
+	evicted bool
+}
+
+// NewTenantManager creates a tenant manager over a base classifier. The base classifier is shared and is not changed
+// by the manager, learn site wide documents with it directly.
+func NewTenantManager(base Classifier, opts TenantOptions) (*TenantManager, error) {
+	c, ok := base.(*classifier)
+	if !ok {
+		return nil, ErrUnsupportedClassifier
+	}
+
+	if opts.Weight < 0 || opts.Weight > 1 || opts.MaxTenants <= 0 || opts.Dir == "" {
+		return nil, ErrInvalidTenantOptions
+	}
+
+	return &TenantManager{base: c, opts: opts, tenants: make(map[string]*list.Element), lru: list.New()}, nil
+}
+
+// Learn learns a document for a single tenant
+func (m *TenantManager) Learn(id string, doc []string, category int) error {
+	if category < 0 || category >= m.base.Tree.CategoryCount() {
+		return ErrInvalidCategory
+	}
+
+	for {
+		t, err := m.tenant(id)
+		if err != nil {
+			return err
+		}
+
+		t.c.mu.Lock()
+		// the tenant may have been saved and evicted since it was looked up, learning it then would be lost
+		if t.evicted {
+			t.c.mu.Unlock()
+			continue
+		}
+
+		err = t.c.learnBatch([]Document{{Words: doc, Category: category}})
+		if err == nil {
+			t.dirty = true
+		}
+		t.c.mu.Unlock()
+		return err
+	}
+}
+
+// Score scores a document for a tenant, interpolating the tenant's model with the base model
+func (m *TenantManager) Score(id string, doc []string) (*ScoreResult, error) {
+	t, err := m.tenant(id)
+	if err != nil {
+		return nil, err
+	}
+
+	baseLock := m.base.lock()
+	baseLock.RLock()
+	defer baseLock.RUnlock()
+
+	t.c.mu.RLock()
+	defer t.c.mu.RUnlock()
+
+	if m.base.Tree.UniqueWords() == 0 {
+		return nil, ErrUntrained
+	}
+
+	model := &tenantModel{base: m.base.view(), weight: m.opts.Weight}
+	// a tenant that has learned nothing has no probabilities of its own to mix in
+	if t.c.Tree.UniqueWords() > 0 {
+		model.tenant = t.c.view()
+	}
+	return score(model, doc)
+}
+
+// Flush saves every tenant model that changed since it was last saved
+func (m *TenantManager) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for e := m.lru.Front(); e != nil; e = e.Next() {
+		if err := m.save(e.Value.(*tenant), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenant returns a tenant's model, loading it or creating it if it is not in memory, and evicting the least
+// recently used tenants that no longer fit
+func (m *TenantManager) tenant(id string) (*tenant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.tenants[id]; ok {
+		m.lru.MoveToFront(e)
+		return e.Value.(*tenant), nil
+	}
+
+	c, err := m.load(id)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &tenant{id: id, c: c}
+	m.tenants[id] = m.lru.PushFront(t)
+
+	for m.lru.Len() > m.opts.MaxTenants {
+		e := m.lru.Back()
+		if err := m.save(e.Value.(*tenant), true); err != nil {
+			return nil, err
+		}
+		m.lru.Remove(e)
+		delete(m.tenants, e.Value.(*tenant).id)
+	}
+	return t, nil
+}
+
+// load reads a tenant's model from its file, or creates an empty one if the tenant was never saved
+func (m *TenantManager) load(id string) (*classifier, error) {
+	f, err := os.Open(m.path(id))
+	if os.IsNotExist(err) {
+		c, err := newClassifier(m.base.Tree.CategoryCount(), m.base.SmoothingFactor, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.Smoother, c.Unknown = m.base.Smoother, m.base.Unknown
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	c := &classifier{}
+	if err := gob.NewDecoder(f).Decode(c); err != nil {
+		return nil, err
+	}
+
+	if c.Tree == nil || c.Tree.CategoryCount() != m.base.Tree.CategoryCount() {
+		return nil, ErrLabelMismatch
+	}
+	return c, nil
+}
+
+// save writes a tenant's model to its file if it changed, and marks it evicted if it is about to be dropped
+func (m *TenantManager) save(t *tenant, evict bool) error {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	if t.dirty {
+		err := writeFile(m.path(t.id), func(w io.Writer) error {
+			return gob.NewEncoder(w).Encode(t.c)
+		})
+		if err != nil {
+			return err
+		}
+		t.dirty = false
+	}
+
+	t.evicted = evict
+	return nil
+}
+
+// path is the file of a tenant's model, the id is escaped so that any id is a single file name
+func (m *TenantManager) path(id string) string {
+	return filepath.Join(m.opts.Dir, url.PathEscape(id)+".gob")
+}
+
+// tenantModel is the wordModel of a tenant, it mixes the probabilities of the tenant's model with the base model's
+type tenantModel struct {
+	base   *classifierView
+	tenant *classifierView
+	weight float64
+}
+
+func (t *tenantModel) categoryCount() int {
+	return t.base.categoryCount()
+}
+
+// logPriors interpolates the priors the same way as the words. The tenant's priors are add one smoothed, since a
+// tenant that has only learned some categories would otherwise rule the others out whatever a document holds.
+func (t *tenantModel) logPriors() []float64 {
+	priors := t.base.logPriors()
+	if t.tenant == nil {
+		return priors
+	}
+
+	totals := t.tenant.smoothing.Totals
+	sum := 0.0
+	for _, total := range totals {
+		sum += total
+	}
+
+	for i, total := range totals {
+		prior := (total + 1) / (sum + float64(len(totals)))
+		priors[i] = math.Log(t.weight*prior + (1-t.weight)*math.Exp(priors[i]))
+	}
+	return priors
+}
+
+func (t *tenantModel) wordLogProbs(word string, dst []float64) (bool, bool, error) {
+	if t.tenant == nil {
+		return t.base.wordLogProbs(word, dst)
+	}
+
+	probs, known, err := t.base.c.getCategoryProbs(word, t.base.smoothing)
+	if err != nil {
+		return known, false, err
+	}
+
+	tenantProbs, tenantKnown, err := t.tenant.c.getCategoryProbs(word, t.tenant.smoothing)
+	if err != nil {
+		return known, false, err
+	}
+
+	known = known || tenantKnown
+	switch {
+	case probs == nil && tenantProbs == nil:
+		return known, false, nil
+	case probs == nil:
+		probs = tenantProbs
+	case tenantProbs != nil:
+		for i := range probs {
+			probs[i] = t.weight*tenantProbs[i] + (1-t.weight)*probs[i]
+		}
+	}
+
+	for i, prob := range probs {
+		dst[i] = math.Log(prob)
+	}
+	return known, true, nil
+}
@@ -0,0 +1,170 @@
+package bayesian
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var tenantDocs = []Document{
+	{Words: []string{"prize", "money", "winner"}, Category: Positive},
+	{Words: []string{"meeting", "agenda", "notes"}, Category: Negative},
+}
+
+func TestNewTenantManager(t *testing.T) {
+	base := trainedClassifier(t, 2, tenantDocs)
+	dir := t.TempDir()
+
+	_, err := NewTenantManager(base, TenantOptions{Weight: 1.5, MaxTenants: 1, Dir: dir})
+	assert.Equal(t, ErrInvalidTenantOptions, err)
+	_, err = NewTenantManager(base, TenantOptions{Weight: 0.5, Dir: dir})
+	assert.Equal(t, ErrInvalidTenantOptions, err)
+	_, err = NewTenantManager(base, TenantOptions{Weight: 0.5, MaxTenants: 1})
+	assert.Equal(t, ErrInvalidTenantOptions, err)
+
+	model, err := Compile(base)
+	assert.NoError(t, err)
+	_, err = NewTenantManager(model, TenantOptions{Weight: 0.5, MaxTenants: 1, Dir: dir})
+	assert.Equal(t, ErrUnsupportedClassifier, err)
+}
+
+func TestTenantBackoff(t *testing.T) {
+	base := trainedClassifier(t, 2, tenantDocs)
+	m, err := NewTenantManager(base, TenantOptions{Weight: 0.8, MaxTenants: 4, Dir: t.TempDir()})
+	assert.NoError(t, err)
+
+	// a new tenant scores exactly like the base model
+	doc := []string{"prize", "meeting", "notes"}
+	want, err := base.Score(doc)
+	assert.NoError(t, err)
+	got, err := m.Score("alice", doc)
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, want.Posteriors, got.Posteriors, 1e-12)
+
+	// alice has meetings about prizes, bob does not
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, m.Learn("alice", []string{"prize", "meeting"}, Negative))
+	}
+	assert.Equal(t, ErrInvalidCategory, m.Learn("alice", []string{"prize"}, 2))
+
+	alice, err := m.Score("alice", []string{"prize"})
+	assert.NoError(t, err)
+	assert.Equal(t, Negative, alice.Best)
+
+	bob, err := m.Score("bob", []string{"prize"})
+	assert.NoError(t, err)
+	assert.Equal(t, Positive, bob.Best)
+
+	// words only the tenant learned are known
+	assert.NoError(t, m.Learn("bob", []string{"lottery"}, Positive))
+	bob, err = m.Score("bob", []string{"lottery"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bob.Known)
+	assert.Equal(t, Positive, bob.Best)
+
+	// the base model is left alone
+	_, known := base.Tree.Find("lottery")
+	assert.False(t, known)
+
+	// a tenant trusted fully that has only learned one category can still score the other
+	m, err = NewTenantManager(base, TenantOptions{Weight: 1, MaxTenants: 1, Dir: t.TempDir()})
+	assert.NoError(t, err)
+	assert.NoError(t, m.Learn("carol", []string{"meeting", "agenda"}, Negative))
+	carol, err := m.Score("carol", []string{"prize", "money", "winner"})
+	assert.NoError(t, err)
+	assert.Equal(t, Positive, carol.Best)
+
+	untrained, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+	m, err = NewTenantManager(untrained, TenantOptions{Weight: 0.5, MaxTenants: 1, Dir: t.TempDir()})
+	assert.NoError(t, err)
+	_, err = m.Score("alice", []string{"prize"})
+	assert.Equal(t, ErrUntrained, err)
+}
+
+func TestTenantEviction(t *testing.T) {
+	base := trainedClassifier(t, 2, tenantDocs)
+	dir := t.TempDir()
+	m, err := NewTenantManager(base, TenantOptions{Weight: 0.8, MaxTenants: 2, Dir: dir})
+	assert.NoError(t, err)
+
+	ids := []string{"alice", "bob", "carol/../dave"}
+	for _, id := range ids {
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, m.Learn(id, []string{"prize", id}, Negative))
+		}
+	}
+
+	// alice was the least recently used, so alice was saved and evicted
+	assert.Equal(t, 2, m.lru.Len())
+	_, ok := m.tenants["alice"]
+	assert.False(t, ok)
+	files, err := filepath.Glob(filepath.Join(dir, "*.gob"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	// and is loaded again with what was learned
+	result, err := m.Score("alice", []string{"prize"})
+	assert.NoError(t, err)
+	assert.Equal(t, Negative, result.Best)
+	assert.Equal(t, 2, m.lru.Len())
+
+	// every tenant survives a new manager once flushed
+	assert.NoError(t, m.Flush())
+	files, err = filepath.Glob(filepath.Join(dir, "*.gob"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	m, err = NewTenantManager(base, TenantOptions{Weight: 0.8, MaxTenants: 1, Dir: dir})
+	assert.NoError(t, err)
+	for _, id := range ids {
+		result, err := m.Score(id, []string{"prize", id})
+		assert.NoError(t, err)
+		assert.Equal(t, Negative, result.Best)
+		assert.Equal(t, 2, result.Known)
+	}
+
+	// a tenant saved for other categories is rejected
+	other, err := NewClassifier(3, 1)
+	assert.NoError(t, err)
+	f, err := os.Create(filepath.Join(dir, "erin.gob"))
+	assert.NoError(t, err)
+	assert.NoError(t, gob.NewEncoder(f).Encode(other))
+	assert.NoError(t, f.Close())
+	_, err = m.Score("erin", []string{"prize"})
+	assert.Equal(t, ErrLabelMismatch, err)
+}
+
+func TestTenantConcurrent(t *testing.T) {
+	base := trainedClassifier(t, 2, tenantDocs)
+	m, err := NewTenantManager(base, TenantOptions{Weight: 0.8, MaxTenants: 2, Dir: t.TempDir()})
+	assert.NoError(t, err)
+
+	ids := []string{"alice", "bob", "carol", "dave"}
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				assert.NoError(t, m.Learn(id, []string{"prize", id}, Negative))
+				_, err := m.Score(id, []string{"prize"})
+				assert.NoError(t, err)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	// no learning was lost to eviction
+	for _, id := range ids {
+		tenant, err := m.tenant(id)
+		assert.NoError(t, err)
+		counts, ok := tenant.c.Tree.Find(id)
+		assert.True(t, ok)
+		assert.Equal(t, []int{20, 0}, counts)
+	}
+}
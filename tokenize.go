@@ -0,0 +1,19 @@
+package bayesian
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize splits text into lower cased words, treating every rune that is not a letter or digit as a separator
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i := range words {
+		words[i] = strings.ToLower(words[i])
+	}
+
+	return words
+}
@@ -0,0 +1,165 @@
+package bayesian
+
+import (
+	"errors"
+	"time"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// Tx collects documents to learn and applies them to the classifier all at once when committed
+type Tx struct {
+	c          Classifier
+	categories int
+	docs       []Document
+	done       bool
+}
+
+// ErrNotAtomic is an error we throw when learning a batch with a tree that can neither take back an insert nor check
+// one beforehand
+var ErrNotAtomic = errors.New("bayesian: tree cannot learn a batch atomically")
+
+// ErrTxDone is an error we throw when using a transaction that has already been committed or rolled back
+var ErrTxDone = errors.New("bayesian: transaction has already been committed or rolled back")
+
+// Begin starts a new transaction, nothing learned through it is visible until Commit
+func (c *classifier) Begin() *Tx {
+	return &Tx{c: c, categories: c.Tree.CategoryCount()}
+}
+
+// Learn adds a document to the transaction, the category is checked right away
+func (tx *Tx) Learn(doc []string, category int) error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	if category < 0 || category >= tx.categories {
+		return ErrInvalidCategory
+	}
+
+	tx.docs = append(tx.docs, Document{Words: doc, Category: category})
+	return nil
+}
+
+// Commit learns every document in the transaction atomically
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	tx.done = true
+	return tx.c.LearnBatch(tx.docs)
+}
+
+// Rollback discards the transaction without learning anything
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	tx.done = true
+	tx.docs = nil
+	return nil
+}
+
+// LearnBatch learns every document, or none of them if any fails. Categories are validated before anything is changed,
+// and if the tree fails partway through, the words already inserted are removed again. Trees that cannot remove words
+// have every insert of the batch checked before the first one. Concurrent calls to Scores see either none or all of
+// the batch.
+func (c *classifier) LearnBatch(docs []Document) error {
+	for _, doc := range docs {
+		if doc.Category < 0 || doc.Category >= c.Tree.CategoryCount() {
+			return ErrInvalidCategory
+		}
+	}
+
+	lock := c.lock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	return c.learnBatch(docs)
+}
+
+// learnBatch is LearnBatch for callers that already validated the batch and hold the write lock
+func (c *classifier) learnBatch(docs []Document) error {
+	if decayer, ok := c.Tree.(radix.Decayer); ok && c.AutoDecay {
+		decayer.Decay(time.Now())
+	}
+	// dropped epochs change counts no batch accounts for, so what was derived from them is built again
+	if windower, ok := c.Tree.(radix.Windower); ok && windower.Advance(time.Now()) > 0 {
+		c.resetUnknownCounts()
+	}
+
+	if err := c.checkBatch(docs); err != nil {
+		return err
+	}
+
+	// the pseudo counts are worked out from the counts before the batch, and only kept if all of it is learned
+	unknown := c.updatedUnknownCounts(docs)
+
+	// a tree read without the lock builds the batch privately and publishes it once it is all in
+	batcher, batched := c.Tree.(radix.Batcher)
+	if batched {
+		batcher.Begin()
+	}
+
+	for i, doc := range docs {
+		for j, word := range doc.Words {
+			if err := c.Tree.Insert(word, doc.Category); err != nil {
+				if batched {
+					batcher.Rollback()
+				} else {
+					c.undo(docs[:i], doc.Words[:j], doc.Category)
+				}
+				return err
+			}
+		}
+	}
+
+	if batched {
+		batcher.Commit()
+	}
+	if unknown != nil {
+		c.keepUnknownCounts(unknown)
+	}
+	return nil
+}
+
+// checkBatch makes sure a batch can be applied to a tree that cannot take back an insert, before anything changes
+func (c *classifier) checkBatch(docs []Document) error {
+	checker, ok := c.Tree.(radix.Checker)
+	if !ok {
+		if _, ok := c.Tree.(radix.Remover); !ok {
+			return ErrNotAtomic
+		}
+		return nil
+	}
+
+	for _, doc := range docs {
+		for _, word := range doc.Words {
+			if err := checker.CheckInsert(word, doc.Category); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// undo removes the words of fully applied documents and a partially applied one from the tree
+func (c *classifier) undo(applied []Document, partial []string, category int) {
+	// a tree that cannot remove words had the batch checked up front, its inserts do not fail
+	remover, ok := c.Tree.(radix.Remover)
+	if !ok {
+		return
+	}
+
+	for i := len(partial) - 1; i >= 0; i-- {
+		_ = remover.Remove(partial[i], category)
+	}
+
+	for i := len(applied) - 1; i >= 0; i-- {
+		for j := len(applied[i].Words) - 1; j >= 0; j-- {
+			_ = remover.Remove(applied[i].Words[j], applied[i].Category)
+		}
+	}
+}
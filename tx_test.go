@@ -0,0 +1,182 @@
+package bayesian
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+	"github.com/stretchr/testify/assert"
+)
+
+// removableTree is a tree we can both insert into and remove from
+type removableTree interface {
+	radix.Tree
+	radix.Remover
+}
+
+// failingTree fails every insert once a budget of successful inserts is used up
+type failingTree struct {
+	removableTree
+	budget int
+}
+
+func (f *failingTree) Insert(needle string, category int) error {
+	if f.budget == 0 {
+		return radix.ErrCannotCreateNode
+	}
+	f.budget--
+	return f.removableTree.Insert(needle, category)
+}
+
+func TestLearnBatchRollsBack(t *testing.T) {
+	tree, err := radix.New(2)
+	assert.NoError(t, err)
+	failing := &failingTree{removableTree: tree.(removableTree), budget: 4}
+	c := &classifier{Tree: failing, SmoothingFactor: 1}
+
+	assert.NoError(t, c.Learn([]string{"keep"}, 1))
+
+	err = c.LearnBatch([]Document{
+		{Words: []string{"a", "b"}, Category: 0},
+		{Words: []string{"c", "d", "keep"}, Category: 1},
+	})
+	assert.Equal(t, radix.ErrCannotCreateNode, err)
+
+	// only the word learned before the batch survives
+	assert.Equal(t, 1, tree.UniqueWords())
+	assert.Equal(t, []int{0, 1}, tree.GetTotals())
+	counts, found := tree.Find("keep")
+	assert.True(t, found)
+	assert.Equal(t, []int{0, 1}, counts)
+	_, found = tree.Find("a")
+	assert.False(t, found)
+}
+
+// checkingTree is a tree that cannot remove words and refuses one word up front
+type checkingTree struct {
+	radix.Tree
+	refused string
+}
+
+func (c *checkingTree) CheckInsert(needle string, category int) error {
+	if needle == c.refused {
+		return radix.ErrCannotCreateNode
+	}
+	return nil
+}
+
+func TestLearnBatchChecksTreesThatCannotRemove(t *testing.T) {
+	tree, err := radix.New(2)
+	assert.NoError(t, err)
+	c := &classifier{Tree: &checkingTree{Tree: tree, refused: "d"}, SmoothingFactor: 1}
+
+	assert.NoError(t, c.Learn([]string{"keep"}, 1))
+	err = c.LearnBatch([]Document{
+		{Words: []string{"a", "b"}, Category: 0},
+		{Words: []string{"c", "d"}, Category: 1},
+	})
+	assert.Equal(t, radix.ErrCannotCreateNode, err)
+
+	// nothing of the batch was inserted
+	assert.Equal(t, []int{0, 1}, tree.GetTotals())
+	_, found := tree.Find("a")
+	assert.False(t, found)
+
+	// a tree that can neither remove nor check is refused
+	plain := &classifier{Tree: struct{ radix.Tree }{tree}, SmoothingFactor: 1}
+	assert.Equal(t, ErrNotAtomic, plain.Learn([]string{"a"}, 0))
+	assert.Equal(t, []int{0, 1}, tree.GetTotals())
+}
+
+func TestLearnBatchValidatesFirst(t *testing.T) {
+	c, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+
+	err = c.LearnBatch([]Document{{Words: []string{"a"}, Category: 0}, {Words: []string{"b"}, Category: 2}})
+	assert.Equal(t, ErrInvalidCategory, err)
+	assert.Equal(t, 0, c.(Inspector).Stats().UniqueWords)
+}
+
+func TestTx(t *testing.T) {
+	c, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+
+	tx := c.Begin()
+	assert.NoError(t, tx.Learn([]string{"spam", "eggs"}, 1))
+	assert.Equal(t, ErrInvalidCategory, tx.Learn([]string{"spam"}, -1))
+	assert.NoError(t, tx.Learn([]string{"ham"}, 0))
+	assert.Equal(t, 0, c.(Inspector).Stats().UniqueWords)
+
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, []int{1, 2}, c.(Inspector).Stats().CategoryTotals)
+	assert.Equal(t, ErrTxDone, tx.Commit())
+	assert.Equal(t, ErrTxDone, tx.Learn([]string{"late"}, 0))
+
+	tx = c.Begin()
+	assert.NoError(t, tx.Learn([]string{"discarded"}, 0))
+	assert.NoError(t, tx.Rollback())
+	assert.Equal(t, ErrTxDone, tx.Rollback())
+	assert.Equal(t, 3, c.(Inspector).Stats().UniqueWords)
+}
+
+func TestLearnBatchIsAtomicForReaders(t *testing.T) {
+	c, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Learn([]string{"seed"}, 0))
+
+	batch := []Document{{Words: []string{"a", "b", "c"}, Category: 1}, {Words: []string{"d"}, Category: 1}}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			assert.NoError(t, c.LearnBatch(batch))
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		_, _, _, err := c.Scores([]string{"a"})
+		assert.NoError(t, err)
+		// a reader never sees half of a batch, so the total is always a whole number of batches
+		assert.Equal(t, 0, c.(Inspector).Stats().CategoryTotals[1]%4)
+	}
+	wg.Wait()
+}
+
+func TestLearnBatchIsAtomicForSnapshotReaders(t *testing.T) {
+	c, err := NewClassifier(2, 1, WithPersistentTree())
+	assert.NoError(t, err)
+	assert.NoError(t, c.Learn([]string{"seed"}, 0))
+
+	words := make([]string, 5000)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, c.LearnBatch([]Document{{Words: words, Category: 1}}))
+	}()
+
+	// scoring does not wait for the batch, but it never sees part of it
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+
+		result, err := c.Score(words)
+		assert.NoError(t, err)
+		if result.Known != 0 && result.Known != len(words) {
+			t.Fatalf("saw %d of %d words", result.Known, len(words))
+		}
+	}
+
+	result, err := c.Score(words)
+	assert.NoError(t, err)
+	assert.Equal(t, len(words), result.Known)
+}
@@ -0,0 +1,367 @@
+package bayesian
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// UnknownPolicy decides how a word the classifier has never learned contributes to a score
+type UnknownPolicy int
+
+const (
+	// UnknownSmooth gives unseen words the probability the smoother gives a count of 0, this is the default
+	UnknownSmooth UnknownPolicy = iota
+	// UnknownIgnore leaves unseen words out of the score entirely
+	UnknownIgnore
+	// UnknownHapax scores unseen words as a learned <UNK> pseudo word, whose counts are the number of words seen
+	// exactly once (hapax legomena) in each category
+	UnknownHapax
+	// UnknownShape backs off to the counts of every known word with the same character class shape, so "x9x" for
+	// "v1agra", falling back to smoothing when no known word has that shape
+	UnknownShape
+)
+
+// ErrInvalidUnknownPolicy is an error we throw when an unknown token policy is not one of the defined ones
+var ErrInvalidUnknownPolicy = errors.New("bayesian: invalid unknown token policy")
+
+// ErrInvalidFuzzyMatching is an error we throw when the edit distance or penalty of fuzzy matching is out of range
+var ErrInvalidFuzzyMatching = errors.New("bayesian: invalid fuzzy matching")
+
+func (p UnknownPolicy) String() string {
+	switch p {
+	case UnknownSmooth:
+		return "smooth"
+	case UnknownIgnore:
+		return "ignore"
+	case UnknownHapax:
+		return "hapax"
+	case UnknownShape:
+		return "shape"
+	default:
+		return fmt.Sprintf("UnknownPolicy(%d)", int(p))
+	}
+}
+
+// ParseUnknownPolicy turns the name of a policy, as returned by String, back into the policy
+func ParseUnknownPolicy(name string) (UnknownPolicy, error) {
+	for p := UnknownSmooth; p <= UnknownShape; p++ {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return 0, ErrInvalidUnknownPolicy
+}
+
+// WithUnknownPolicy sets how words the classifier has never learned are scored
+func WithUnknownPolicy(p UnknownPolicy) Option {
+	return func(c *classifier) error {
+		if p < UnknownSmooth || p > UnknownShape {
+			return ErrInvalidUnknownPolicy
+		}
+
+		c.Unknown = p
+		return nil
+	}
+}
+
+// WithFuzzyMatching scores a word the classifier never learned with the counts of the nearest known word, at most
+// maxEdits insertions, deletions or substitutions of a character away, so "v1agra" and "viagrra" count as "viagra".
+// The borrowed counts are multiplied by penalty once per edit, and among equally near words the most frequent one is
+// used. Words of maxEdits characters or fewer are never matched, since almost any short word is that near, and
+// words with no known word near enough fall back to the unknown policy. It needs a tree that can find words by edit
+// distance, the default, persistent, decayed and windowed trees can, and with any other tree creating the classifier
+// fails with ErrInvalidFuzzyMatching. A classifier using it cannot be compiled.
+func WithFuzzyMatching(maxEdits int, penalty float64) Option {
+	return func(c *classifier) error {
+		if maxEdits <= 0 || !(penalty > 0 && penalty <= 1) {
+			return ErrInvalidFuzzyMatching
+		}
+
+		c.FuzzyEdits, c.FuzzyPenalty = maxEdits, penalty
+		return nil
+	}
+}
+
+// fuzzyCounts borrows the counts of the nearest known word for an unseen one, or returns nil when fuzzy matching is
+// off or no known word is near enough
+func (c *classifier) fuzzyCounts(text string, s *SmoothingContext) []float64 {
+	if c.FuzzyEdits == 0 || utf8.RuneCountInString(text) <= c.FuzzyEdits {
+		return nil
+	}
+
+	finder, ok := s.tree.(radix.FuzzyFinder)
+	if !ok {
+		return nil
+	}
+
+	matches := finder.FindFuzzy(text, c.FuzzyEdits)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	// among the nearest words the most frequent is the likeliest to be the one meant
+	best, bestTotal := matches[0], total(matches[0].Counts)
+	for _, match := range matches[1:] {
+		if match.Distance > best.Distance {
+			break
+		}
+		if matchTotal := total(match.Counts); matchTotal > bestTotal {
+			best, bestTotal = match, matchTotal
+		}
+	}
+
+	counts := floatCounts(best.Counts)
+	// decayed counts are read at the time of the smoothing context rather than rounded at the clock
+	if _, ok := s.tree.(radix.Decayer); ok {
+		counts, _ = c.find(best.Word, s)
+	}
+	return scaled(counts, math.Pow(c.FuzzyPenalty, float64(best.Distance)))
+}
+
+// scaled multiplies counts by a factor in place and returns them
+func scaled(counts []float64, factor float64) []float64 {
+	for i := range counts {
+		counts[i] *= factor
+	}
+	return counts
+}
+
+// unknownFade is how much the pseudo counts, built from the counts at the clock of a decayed tree, have faded by the
+// time the smoothing context reads at
+func unknownFade(u *unknownCounts, s *SmoothingContext) float64 {
+	decayer, ok := s.tree.(radix.Decayer)
+	if !ok || !s.at.After(u.clock) {
+		return 1
+	}
+	return math.Exp2(-float64(s.at.Sub(u.clock)) / float64(decayer.HalfLife()))
+}
+
+// total sums counts
+func total(counts []int) int {
+	sum := 0
+	for _, count := range counts {
+		sum += count
+	}
+	return sum
+}
+
+// unknownCounts holds the pseudo counts derived from the vocabulary that unseen words are scored with. They are never
+// changed once built, learning replaces them with updated ones.
+type unknownCounts struct {
+	hapax  []int
+	shapes map[string][]int
+	// tree is the version of the tree they hold the counts of, a snapshot for trees that can take one
+	tree radix.Tree
+	// clock is the clock of a decayed tree when they were built
+	clock time.Time
+}
+
+// staleDecay is the share of a half-life after which the pseudo counts of a decayed tree are built again, they are
+// not updated as words are learned since learning also moves the clock every count is rounded at
+const staleDecay = 16
+
+// unknownCountsFor returns the pseudo counts for the tree of a smoothing context, so that unseen words are scored
+// against the same version of the tree as known ones. They are built on first use and then kept up to date as
+// batches are learned. The caller must hold at least the read lock, unless the tree can be snapshotted.
+func (c *classifier) unknownCountsFor(s *SmoothingContext) *unknownCounts {
+	c.cacheMu.Lock()
+	u := c.cachedUnknownCounts(s)
+	c.cacheMu.Unlock()
+	if u != nil {
+		return u
+	}
+
+	// the vocabulary is walked without holding the cache lock, so readers of a cached version never wait for it
+	u = buildUnknownCounts(s.tree)
+	if decayer, ok := s.tree.(radix.Decayer); ok {
+		u.clock = decayer.Clock()
+	}
+
+	// the counts of a snapshot that is no longer current are not kept, later readers would never use them
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if sameVersion(c.Tree, s.tree) {
+		c.unknown, c.previousUnknown = u, nil
+	}
+	return u
+}
+
+// cachedUnknownCounts returns the kept pseudo counts of the context's version, or nil if they have to be built. The
+// counts of the version before the last batch are kept as well, for readers whose snapshot predates the batch.
+func (c *classifier) cachedUnknownCounts(s *SmoothingContext) *unknownCounts {
+	for _, u := range []*unknownCounts{c.unknown, c.previousUnknown} {
+		if u == nil || !sameVersion(u.tree, s.tree) {
+			continue
+		}
+
+		if decayer, ok := s.tree.(radix.Decayer); ok && s.at.Sub(u.clock) > decayer.HalfLife()/staleDecay {
+			return nil
+		}
+		return u
+	}
+	return nil
+}
+
+// sameVersion reports whether two trees hold the same counts, because they are the same tree or snapshots of the
+// same version
+func sameVersion(a radix.Tree, b radix.Tree) bool {
+	if snapshotter, ok := a.(radix.Snapshotter); ok {
+		return snapshotter.SameVersion(b)
+	}
+	return a == b
+}
+
+// resetUnknownCounts drops the pseudo counts, so that they are built again from the changed vocabulary
+func (c *classifier) resetUnknownCounts() {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	c.unknown, c.previousUnknown = nil, nil
+}
+
+// updatedUnknownCounts returns the pseudo counts as they will be once a batch is learned, from the counts its words
+// have before it, or nil when there are none to keep up to date. The caller must hold the write lock.
+func (c *classifier) updatedUnknownCounts(docs []Document) *unknownCounts {
+	c.cacheMu.Lock()
+	u := c.unknown
+	c.cacheMu.Unlock()
+
+	// the pseudo counts of a decayed tree are rebuilt as the clock moves, and trees that cannot walk have none
+	_, decays := c.Tree.(radix.Decayer)
+	if u == nil || decays || u.hapax == nil || !sameVersion(u.tree, c.Tree) {
+		return nil
+	}
+
+	added := make(map[string][]int)
+	for _, doc := range docs {
+		for _, word := range doc.Words {
+			counts, ok := added[word]
+			if !ok {
+				counts = make([]int, len(u.hapax))
+				added[word] = counts
+			}
+			counts[doc.Category]++
+		}
+	}
+
+	next := &unknownCounts{hapax: append([]int(nil), u.hapax...), shapes: make(map[string][]int, len(u.shapes))}
+	for shape, counts := range u.shapes {
+		next.shapes[shape] = counts
+	}
+
+	copied := make(map[string]bool)
+	for word, counts := range added {
+		before, _ := c.Tree.Find(word)
+		after := make([]int, len(counts))
+		for i := range after {
+			after[i] = counts[i]
+			if before != nil {
+				after[i] += before[i]
+			}
+		}
+
+		// a word stops or starts being a hapax legomenon
+		if total(before) == 1 {
+			for i, count := range before {
+				next.hapax[i] -= count
+			}
+		}
+		if total(after) == 1 {
+			for i, count := range after {
+				next.hapax[i] += count
+			}
+		}
+
+		shape := wordShape(word)
+		// the counts of a shape are shared with the previous version until the batch changes them
+		if !copied[shape] {
+			shapeCounts := make([]int, len(counts))
+			copy(shapeCounts, next.shapes[shape])
+			next.shapes[shape] = shapeCounts
+			copied[shape] = true
+		}
+		for i, count := range counts {
+			next.shapes[shape][i] += count
+		}
+	}
+	return next
+}
+
+// keepUnknownCounts makes the updated pseudo counts of a learned batch the current ones
+func (c *classifier) keepUnknownCounts(u *unknownCounts) {
+	u.tree = c.Tree
+	if snapshotter, ok := c.Tree.(radix.Snapshotter); ok {
+		u.tree = snapshotter.Snapshot()
+	}
+
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	c.unknown, c.previousUnknown = u, c.unknown
+}
+
+// buildUnknownCounts walks the vocabulary once, collecting the hapax legomena and the counts of each word shape
+func buildUnknownCounts(tree radix.Tree) *unknownCounts {
+	u := &unknownCounts{shapes: make(map[string][]int), tree: tree}
+	walker, ok := tree.(radix.Walker)
+	if !ok {
+		return u
+	}
+
+	u.hapax = make([]int, tree.CategoryCount())
+	walker.Walk(func(word string, counts []int) bool {
+		total := 0
+		for _, count := range counts {
+			total += count
+		}
+
+		if total == 1 {
+			for i, count := range counts {
+				u.hapax[i] += count
+			}
+		}
+
+		shape := wordShape(word)
+		shapeCounts, ok := u.shapes[shape]
+		if !ok {
+			shapeCounts = make([]int, len(counts))
+			u.shapes[shape] = shapeCounts
+		}
+		for i, count := range counts {
+			shapeCounts[i] += count
+		}
+		return true
+	})
+
+	return u
+}
+
+// wordShape maps a word to its character classes, upper case letters become X, other letters x, digits 9 and
+// punctuation is kept, with runs of the same class collapsed, so "Viagra" becomes "Xx" and "v1agra!" becomes "x9x!"
+func wordShape(word string) string {
+	var b strings.Builder
+	var last rune
+	for _, r := range word {
+		class := r
+		switch {
+		case unicode.IsUpper(r):
+			class = 'X'
+		case unicode.IsLetter(r):
+			class = 'x'
+		case unicode.IsDigit(r):
+			class = '9'
+		}
+
+		if class != last {
+			b.WriteRune(class)
+			last = class
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,228 @@
+package bayesian
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unknownDocs have a large category 0 and a small category 1 full of words seen only once, so an unseen word is far
+// more likely to be one of category 1's
+var unknownDocs = []Document{
+	{Words: []string{"the", "the", "the", "the", "report", "report", "report", "meeting", "meeting"}, Category: 0},
+	{Words: []string{"v1agra", "c4sino", "l0tto", "the"}, Category: 1},
+}
+
+func TestUnknownPolicies(t *testing.T) {
+	doc := []string{"the", "unseenword"}
+
+	smooth, err := trainedClassifier(t, 2, unknownDocs, WithUnknownPolicy(UnknownSmooth)).Score(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, smooth.Known)
+	assert.Equal(t, 1, smooth.Unknown)
+
+	ignore, err := trainedClassifier(t, 2, unknownDocs, WithUnknownPolicy(UnknownIgnore)).Score(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ignore.Unknown)
+	only, err := trainedClassifier(t, 2, unknownDocs, WithUnknownPolicy(UnknownIgnore)).Score([]string{"the"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ignore.Scores[0].Cmp(only.Scores[0]))
+
+	// hapax legomena are mostly in category 1, so the <UNK> word pushes an unseen word towards it
+	hapax, err := trainedClassifier(t, 2, unknownDocs, WithUnknownPolicy(UnknownHapax)).Score(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hapax.Unknown)
+	assert.True(t, hapax.Scores[1].Cmp(smooth.Scores[1]) > 0)
+
+	// "m0ney" has the same shape as the category 1 words, "money" as the category 0 words
+	shape := trainedClassifier(t, 2, unknownDocs, WithUnknownPolicy(UnknownShape))
+	r, err := shape.Score([]string{"m0ney"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r.Best)
+	assert.Equal(t, 1, r.Unknown)
+	r, err = shape.Score([]string{"money"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.Best)
+
+	// a shape nobody has is smoothed like any other unseen word
+	r, err = shape.Score([]string{"!!"})
+	assert.NoError(t, err)
+	s, err := trainedClassifier(t, 2, unknownDocs, WithUnknownPolicy(UnknownSmooth)).Score([]string{"!!"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.Scores[0].Cmp(s.Scores[0]))
+}
+
+func TestUnknownCountsFollowLearning(t *testing.T) {
+	c := trainedClassifier(t, 2, unknownDocs, WithUnknownPolicy(UnknownShape))
+	r, err := c.Score([]string{"m0ney"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r.Best)
+
+	// once enough digit words are learned in category 0 the cached shapes must be rebuilt
+	assert.NoError(t, c.Learn([]string{"q1", "r2d2", "x9", "a1b", "b2c", "c3d", "d4e", "e5f"}, 0))
+	assert.NoError(t, c.Learn([]string{"f6g", "g7h", "h8i", "i9j", "j0k", "k1l", "l2m", "m3n", "n4o"}, 0))
+	r, err = c.Score([]string{"m0ney"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.Best)
+}
+
+func TestUnknownCountsReadTheSnapshot(t *testing.T) {
+	c, err := newClassifier(2, 1, []Option{WithPersistentTree(), WithUnknownPolicy(UnknownHapax)})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Learn([]string{"v1agra", "c4sino", "the", "the"}, 1))
+
+	// a scorer that took its snapshot before a batch gets the pseudo counts of that snapshot
+	before := c.smoothingContext()
+	assert.NoError(t, c.Learn([]string{"lunch", "report", "agenda"}, 0))
+	after := c.smoothingContext()
+
+	assert.Equal(t, []int{0, 2}, c.unknownCountsFor(before).hapax)
+	assert.Equal(t, []int{3, 2}, c.unknownCountsFor(after).hapax)
+	assert.Equal(t, []int{0, 2}, c.unknownCountsFor(before).hapax)
+
+	// and only the counts of the current version are kept
+	assert.True(t, sameVersion(c.Tree, c.unknown.tree))
+	assert.True(t, c.unknownCountsFor(c.smoothingContext()) == c.unknown)
+
+	// once they are kept a batch updates them, and the counts of the version before it stay around for its readers
+	kept := c.unknown
+	assert.NoError(t, c.Learn([]string{"lunch", "minutes"}, 1))
+	latest := c.smoothingContext()
+	assert.Equal(t, []int{2, 3}, c.unknownCountsFor(latest).hapax)
+	assert.True(t, c.unknownCountsFor(after) == kept)
+}
+
+func TestUnknownCountsAreKeptUpToDate(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithPersistentTree()}, {WithArenaTree()}} {
+		c, err := newClassifier(3, 1, append(opts, WithUnknownPolicy(UnknownShape)))
+		assert.NoError(t, err)
+		assert.NoError(t, c.Learn([]string{"seed"}, 0))
+
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 50; i++ {
+			// scoring builds the pseudo counts once, every batch after that updates them
+			_, err := c.Score([]string{"unseen"})
+			assert.NoError(t, err)
+			built := c.unknown
+
+			var docs []Document
+			for j := rng.Intn(3) + 1; j > 0; j-- {
+				words := make([]string, rng.Intn(6))
+				for k := range words {
+					words[k] = []string{"a", "B", "c1", "D2", "x", "Yy", "z!"}[rng.Intn(7)] + fmt.Sprint(rng.Intn(20))
+				}
+				docs = append(docs, Document{Words: words, Category: rng.Intn(3)})
+			}
+			assert.NoError(t, c.LearnBatch(docs))
+
+			u := c.unknownCountsFor(c.smoothingContext())
+			assert.True(t, u == c.unknown)
+			assert.True(t, c.previousUnknown == built)
+			want := buildUnknownCounts(c.Tree)
+			assert.Equal(t, want.hapax, u.hapax)
+			assert.Equal(t, want.shapes, u.shapes)
+		}
+	}
+}
+
+func TestWordShape(t *testing.T) {
+	assert.Equal(t, "Xx", wordShape("Viagra"))
+	assert.Equal(t, "x9x!", wordShape("v1agra!!"))
+	assert.Equal(t, "9.9", wordShape("3.14"))
+	assert.Equal(t, "", wordShape(""))
+}
+
+func TestParseUnknownPolicy(t *testing.T) {
+	for _, p := range []UnknownPolicy{UnknownSmooth, UnknownIgnore, UnknownHapax, UnknownShape} {
+		parsed, err := ParseUnknownPolicy(p.String())
+		assert.NoError(t, err)
+		assert.Equal(t, p, parsed)
+	}
+
+	_, err := ParseUnknownPolicy("guess")
+	assert.Equal(t, ErrInvalidUnknownPolicy, err)
+	_, err = NewClassifier(2, 1, WithUnknownPolicy(UnknownPolicy(9)))
+	assert.Equal(t, ErrInvalidUnknownPolicy, err)
+	assert.Equal(t, "UnknownPolicy(9)", UnknownPolicy(9).String())
+}
+
+func TestWithFuzzyMatching(t *testing.T) {
+	for _, opt := range []Option{WithFuzzyMatching(0, 0.5), WithFuzzyMatching(1, 0), WithFuzzyMatching(1, 1.5)} {
+		_, err := NewClassifier(2, 1, opt)
+		assert.Equal(t, ErrInvalidFuzzyMatching, err)
+	}
+
+	// trees that cannot find words by edit distance are refused, before or after the fuzzy option
+	for _, opt := range []Option{WithArenaTree(), WithFeatureHashing(10, false), WithCountMinSketch(0.01, 0.01)} {
+		_, err := NewClassifier(2, 1, opt, WithFuzzyMatching(1, 0.5))
+		assert.Equal(t, ErrInvalidFuzzyMatching, err)
+		_, err = NewClassifier(2, 1, WithFuzzyMatching(1, 0.5), opt)
+		assert.Equal(t, ErrInvalidFuzzyMatching, err)
+	}
+	for _, opt := range []Option{WithPersistentTree(), WithDecay(time.Hour), WithWindow(2, time.Hour)} {
+		_, err := NewClassifier(2, 1, WithFuzzyMatching(1, 0.5), opt)
+		assert.NoError(t, err)
+	}
+	_, err := OpenDiskClassifier(filepath.Join(t.TempDir(), "model.db"), 2, 1, 16, WithFuzzyMatching(1, 0.5))
+	assert.Equal(t, ErrInvalidFuzzyMatching, err)
+
+	learn := func(c Classifier) {
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, c.Learn([]string{"viagra", "prize", "winner"}, Positive))
+			assert.NoError(t, c.Learn([]string{"meeting", "agenda", "notes", "minutes"}, Negative))
+		}
+	}
+
+	exact, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+	learn(exact)
+	fuzzy, err := NewClassifier(2, 1, WithFuzzyMatching(2, 0.5))
+	assert.NoError(t, err)
+	learn(fuzzy)
+
+	for _, word := range []string{"v1agra", "viagrra", "vi4gr4"} {
+		plain, err := exact.Score([]string{word})
+		assert.NoError(t, err)
+		matched, err := fuzzy.Score([]string{word})
+		assert.NoError(t, err)
+
+		// the misspelling counts as spam but is still reported as unknown
+		assert.True(t, matched.Posteriors[Positive] > plain.Posteriors[Positive], word)
+		assert.Equal(t, Positive, matched.Best, word)
+		assert.Equal(t, 1, matched.Unknown)
+	}
+
+	// each edit weakens the evidence
+	one, err := fuzzy.Score([]string{"v1agra"})
+	assert.NoError(t, err)
+	two, err := fuzzy.Score([]string{"vi4gr4"})
+	assert.NoError(t, err)
+	assert.True(t, one.Posteriors[Positive] > two.Posteriors[Positive])
+
+	// known words, short words and words too far from anything score as before
+	for _, doc := range [][]string{{"viagra"}, {"vi"}, {"zzzzzzzz"}, {"minutes", "xyz"}} {
+		want, err := exact.Score(doc)
+		assert.NoError(t, err)
+		got, err := fuzzy.Score(doc)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, "%v", doc)
+	}
+
+	// a word with no near neighbour falls back to the unknown policy
+	ignoring, err := NewClassifier(2, 1, WithFuzzyMatching(1, 0.5), WithUnknownPolicy(UnknownIgnore))
+	assert.NoError(t, err)
+	learn(ignoring)
+	result, err := ignoring.Score([]string{"v1agra", "zzzzzzzz"})
+	assert.NoError(t, err)
+	assert.Equal(t, Positive, result.Best)
+	only, err := ignoring.Score([]string{"v1agra"})
+	assert.NoError(t, err)
+	assert.Equal(t, only.LogLikelihoods, result.LogLikelihoods)
+
+	_, err = Compile(fuzzy)
+	assert.Equal(t, ErrNotCompilable, err)
+}
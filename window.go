@@ -0,0 +1,81 @@
+package bayesian
+
+import (
+	"errors"
+	"time"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+)
+
+// ErrNotWindowed is an error we throw when rotating a classifier that was not created with WithWindow
+var ErrNotWindowed = errors.New("bayesian: classifier has no window")
+
+// Epoch describes what a classifier learned during one epoch of its window
+type Epoch struct {
+	Start       time.Time
+	Totals      []int
+	UniqueWords int
+}
+
+// WithWindow makes a classifier count only what it learned in its last epochs, each epochLength long, so that
+// "only the last 30 days matter" is exactly true with 30 epochs of a day. The first epoch starts when the classifier
+// is created. Learning moves the window to the current time, and Rotate moves it on a schedule so that old epochs
+// are dropped even when nothing is learned. The epochs are saved with the model.
+func WithWindow(epochs int, epochLength time.Duration) Option {
+	return func(c *classifier) error {
+		tree, err := radix.NewWindow(c.Tree.CategoryCount(), epochs, epochLength, time.Now())
+		if err != nil {
+			return err
+		}
+
+		c.Tree = tree
+		return nil
+	}
+}
+
+// Rotate moves the window of a classifier created with WithWindow to the epoch now falls in, and forgets everything
+// learned in the epochs that fall out of it
+func Rotate(c Classifier, now time.Time) error {
+	cl, ok := c.(*classifier)
+	if !ok {
+		return ErrNotWindowed
+	}
+
+	windower, ok := cl.Tree.(radix.Windower)
+	if !ok {
+		return ErrNotWindowed
+	}
+
+	lock := cl.lock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	if windower.Advance(now) > 0 {
+		cl.resetUnknownCounts()
+	}
+	return nil
+}
+
+// Epochs describes the live epochs of a classifier created with WithWindow, oldest first, so that what the
+// classifier is based on can be audited
+func Epochs(c Classifier) ([]Epoch, error) {
+	cl, ok := c.(*classifier)
+	if !ok {
+		return nil, ErrNotWindowed
+	}
+
+	windower, ok := cl.Tree.(radix.Windower)
+	if !ok {
+		return nil, ErrNotWindowed
+	}
+
+	lock := cl.lock()
+	lock.RLock()
+	defer lock.RUnlock()
+
+	var epochs []Epoch
+	for _, epoch := range windower.Epochs() {
+		epochs = append(epochs, Epoch{Start: epoch.Start, Totals: epoch.Totals, UniqueWords: epoch.UniqueWords})
+	}
+	return epochs, nil
+}
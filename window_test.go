@@ -0,0 +1,69 @@
+package bayesian
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/LegoRemix/bayesian/internal/radix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithWindow(t *testing.T) {
+	_, err := NewClassifier(2, 1, WithWindow(0, time.Hour))
+	assert.Equal(t, radix.ErrInvalidWindow, err)
+
+	plain, err := NewClassifier(2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrNotWindowed, Rotate(plain, time.Now()))
+	_, err = Epochs(plain)
+	assert.Equal(t, ErrNotWindowed, err)
+
+	day := 24 * time.Hour
+	c, err := NewClassifier(2, 1, WithWindow(30, day), WithUnknownPolicy(UnknownHapax))
+	assert.NoError(t, err)
+	epochs, err := Epochs(c)
+	assert.NoError(t, err)
+	start := epochs[0].Start
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, c.Learn([]string{"prize", "money"}, Positive))
+	}
+	assert.NoError(t, c.Learn([]string{"meeting"}, Negative))
+
+	assert.NoError(t, Rotate(c, start.Add(29*day)))
+	assert.NoError(t, c.Learn([]string{"prize", "meeting"}, Negative))
+	result, err := c.Score([]string{"prize"})
+	assert.NoError(t, err)
+	assert.Equal(t, Positive, result.Best)
+
+	epochs, err = Epochs(c)
+	assert.NoError(t, err)
+	assert.Len(t, epochs, 2)
+	assert.Equal(t, []int{1, 10}, epochs[0].Totals)
+	assert.Equal(t, []int{2, 0}, epochs[1].Totals)
+
+	// after 30 days only what was learned in the last epoch counts
+	assert.NoError(t, Rotate(c, start.Add(30*day)))
+	result, err = c.Score([]string{"prize"})
+	assert.NoError(t, err)
+	assert.Equal(t, Negative, result.Best)
+	assert.Equal(t, 1, result.Known)
+
+	_, err = c.Score([]string{"money"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 0}, c.(Inspector).Stats().CategoryTotals)
+
+	// the window is saved with the model
+	m := &Model{Labels: []string{"ham", "spam"}, Classifier: c}
+	buf := new(bytes.Buffer)
+	assert.NoError(t, m.Save(buf))
+	loaded, err := LoadModel(buf)
+	assert.NoError(t, err)
+	loadedEpochs, err := Epochs(loaded.Classifier)
+	assert.NoError(t, err)
+	assert.Len(t, loadedEpochs, 2)
+	reloaded, err := loaded.Classifier.Score([]string{"prize"})
+	assert.NoError(t, err)
+	assert.Equal(t, result, reloaded)
+}